@@ -58,3 +58,27 @@ func IsAppInstalled(app string) bool {
 	output, exitCode, err := ExecuteGetOutput(app, "--is-app-installed")
 	return err == nil && exitCode == 0 && strings.Contains(output, "true")
 }
+
+type LogOptions struct {
+	// Service limits logs to the single app service
+	Service string
+	Follow  bool
+	// Since is passed to the log facility as is, e.g. 1h or 2024-01-01 10:00
+	Since string
+}
+
+// StreamAppLogs streams logs of app services through the ami log action.
+// Every output line is sent to the outputChannel.
+func StreamAppLogs(appDir string, outputChannel chan<- string, logOptions LogOptions) (int, error) {
+	execArgs := []string{"log"}
+	if logOptions.Follow {
+		execArgs = append(execArgs, "--follow")
+	}
+	if logOptions.Since != "" {
+		execArgs = append(execArgs, "--since="+logOptions.Since)
+	}
+	if logOptions.Service != "" {
+		execArgs = append(execArgs, "--"+logOptions.Service)
+	}
+	return ExecuteWithOutputChannel(appDir, outputChannel, execArgs...)
+}
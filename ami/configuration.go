@@ -182,6 +182,26 @@ func WriteFile(workingDir string, content []byte, relativePath string) error {
 	return os.WriteFile(targetPath, content, 0644)
 }
 
+// ReadFile reads file relative to the app directory, remote apps are read over sftp
+func ReadFile(workingDir string, relativePath string) ([]byte, error) {
+	if isRemote, locator := IsRemoteApp(workingDir); isRemote {
+		session, err := locator.OpenAppRemoteSession()
+		if err != nil {
+			return nil, err
+		}
+		defer session.Close()
+
+		file, err := session.sftpSession.Open(path.Join(workingDir, relativePath))
+		if err != nil {
+			return nil, err
+		}
+		defer file.Close()
+		return io.ReadAll(file)
+	}
+
+	return os.ReadFile(path.Join(workingDir, relativePath))
+}
+
 func WriteAppDefinition(workingDir string, configuration map[string]any, appConfigPath string) error {
 	if isRemote, locator := IsRemoteApp(workingDir); isRemote {
 		session, err := locator.OpenAppRemoteSession()
@@ -0,0 +1,378 @@
+package ami
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/tez-capital/tezbake/system"
+	"github.com/tez-capital/tezbake/util"
+	"go.alis.is/common/log"
+)
+
+type CredentialsSourceKind string
+
+const (
+	// CREDENTIALS_SOURCE_FILE reads elevate.enc.json or elevate.json from the app directory (default)
+	CREDENTIALS_SOURCE_FILE    CredentialsSourceKind = "file"
+	CREDENTIALS_SOURCE_ENV     CredentialsSourceKind = "env"
+	CREDENTIALS_SOURCE_COMMAND CredentialsSourceKind = "command"
+	CREDENTIALS_SOURCE_KEYRING CredentialsSourceKind = "keyring"
+	CREDENTIALS_SOURCE_VAULT   CredentialsSourceKind = "vault"
+	// CREDENTIALS_SOURCE_NOPASSWD is used when the remote user may elevate without password (e.g. provisioned sudoers)
+	CREDENTIALS_SOURCE_NOPASSWD CredentialsSourceKind = "nopasswd"
+)
+
+const (
+	DefaultElevationPasswordEnv = "TEZBAKE_ELEVATION_PASSWORD"
+	DefaultKeyringService       = "tezbake"
+	DefaultVaultAddressEnv      = "VAULT_ADDR"
+	DefaultVaultTokenEnv        = "VAULT_TOKEN"
+	DefaultVaultField           = "password"
+)
+
+var vaultClient = &http.Client{Timeout: 30 * time.Second}
+
+// ElevationCredentialsSource describes where to get the elevation password from.
+// It is stored in the locator as `credentials_source`, e.g.:
+//
+//	{ "kind": "command", "command": "pass show bakery/node" }
+//	{ "kind": "env", "variable": "NODE_ELEVATION_PASSWORD" }
+//	{ "kind": "keyring", "service": "tezbake", "account": "node" }
+//	{ "kind": "vault", "address": "https://vault:8200", "path": "secret/data/bakery/node", "field": "password" }
+type ElevationCredentialsSource struct {
+	Kind CredentialsSourceKind `json:"kind"`
+	// User to elevate to, used with su
+	User string `json:"user,omitempty"`
+
+	// env
+	Variable string `json:"variable,omitempty"`
+	// command
+	Command string `json:"command,omitempty"`
+	// keyring
+	Service string `json:"service,omitempty"`
+	Account string `json:"account,omitempty"`
+	// vault
+	Address       string `json:"address,omitempty"`
+	Path          string `json:"path,omitempty"`
+	Field         string `json:"field,omitempty"`
+	TokenVariable string `json:"token_variable,omitempty"`
+}
+
+func (source *ElevationCredentialsSource) IsFile() bool {
+	return source == nil || source.Kind == "" || source.Kind == CREDENTIALS_SOURCE_FILE
+}
+
+func (source *ElevationCredentialsSource) Validate() error {
+	switch source.Kind {
+	case "", CREDENTIALS_SOURCE_FILE, CREDENTIALS_SOURCE_ENV, CREDENTIALS_SOURCE_KEYRING, CREDENTIALS_SOURCE_NOPASSWD:
+	case CREDENTIALS_SOURCE_COMMAND:
+		if strings.TrimSpace(source.Command) == "" {
+			return errors.New("command credentials source requires 'command'")
+		}
+	case CREDENTIALS_SOURCE_VAULT:
+		if source.Path == "" {
+			return errors.New("vault credentials source requires 'path'")
+		}
+	default:
+		return fmt.Errorf("unknown credentials source kind '%s'", source.Kind)
+	}
+	return nil
+}
+
+func (source *ElevationCredentialsSource) keyringAccount(config *RemoteConfiguration) string {
+	if source.Account != "" {
+		return source.Account
+	}
+	return fmt.Sprintf("%s@%s", config.App, config.Host)
+}
+
+func (source *ElevationCredentialsSource) readPassword(config *RemoteConfiguration) (string, error) {
+	switch source.Kind {
+	case CREDENTIALS_SOURCE_ENV:
+		variable := source.Variable
+		if variable == "" {
+			variable = DefaultElevationPasswordEnv
+		}
+		password, ok := os.LookupEnv(variable)
+		if !ok {
+			return "", fmt.Errorf("environment variable '%s' is not set", variable)
+		}
+		return password, nil
+	case CREDENTIALS_SOURCE_COMMAND:
+		return util.ReadSecretFromCommand(source.Command)
+	case CREDENTIALS_SOURCE_KEYRING:
+		service := source.Service
+		if service == "" {
+			service = DefaultKeyringService
+		}
+		return util.ReadSecretFromKeyring(service, source.keyringAccount(config))
+	case CREDENTIALS_SOURCE_VAULT:
+		return source.readVaultPassword()
+	case CREDENTIALS_SOURCE_NOPASSWD:
+		return "", nil
+	}
+	return "", fmt.Errorf("credentials source '%s' does not provide password", source.Kind)
+}
+
+// readVaultPassword reads the password from HashiCorp Vault compatible KV endpoint (v1 or v2)
+func (source *ElevationCredentialsSource) readVaultPassword() (string, error) {
+	address := source.Address
+	if address == "" {
+		address = os.Getenv(DefaultVaultAddressEnv)
+	}
+	if address == "" {
+		return "", fmt.Errorf("vault address not specified and %s is not set", DefaultVaultAddressEnv)
+	}
+	tokenVariable := source.TokenVariable
+	if tokenVariable == "" {
+		tokenVariable = DefaultVaultTokenEnv
+	}
+	field := source.Field
+	if field == "" {
+		field = DefaultVaultField
+	}
+
+	endpoint, err := url.JoinPath(address, "v1", source.Path)
+	if err != nil {
+		return "", fmt.Errorf("invalid vault address - %s", err.Error())
+	}
+	request, err := http.NewRequest(http.MethodGet, endpoint, nil)
+	if err != nil {
+		return "", err
+	}
+	if token := os.Getenv(tokenVariable); token != "" {
+		request.Header.Set("X-Vault-Token", token)
+	}
+
+	log.Debug("Reading elevation password from vault...", "url", endpoint)
+	response, err := vaultClient.Do(request)
+	if err != nil {
+		return "", fmt.Errorf("failed to query vault - %s", err.Error())
+	}
+	defer response.Body.Close()
+	if response.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("failed to read secret '%s' from vault - %s", source.Path, response.Status)
+	}
+
+	var secret struct {
+		Data map[string]any `json:"data"`
+	}
+	if err := json.NewDecoder(response.Body).Decode(&secret); err != nil {
+		return "", fmt.Errorf("failed to decode vault response - %s", err.Error())
+	}
+	data := secret.Data
+	// KV v2 nests the secret in data.data
+	if nested, ok := data["data"].(map[string]any); ok {
+		data = nested
+	}
+	password, ok := data[field].(string)
+	if !ok {
+		return "", fmt.Errorf("field '%s' not found in vault secret '%s'", field, source.Path)
+	}
+	return password, nil
+}
+
+// GetElevationCredentials resolves elevation credentials from the source
+func (source *ElevationCredentialsSource) GetElevationCredentials(config *RemoteConfiguration) (*RemoteElevateCredentials, error) {
+	if err := source.Validate(); err != nil {
+		return nil, err
+	}
+	password, err := source.readPassword(config)
+	if err != nil {
+		return nil, err
+	}
+	return &RemoteElevateCredentials{
+		Kind:       config.Elevate,
+		User:       source.User,
+		Password:   password,
+		Restricted: source.Kind == CREDENTIALS_SOURCE_NOPASSWD,
+	}, nil
+}
+
+// ParseElevationCredentialsSource parses short source specification used on command line:
+//
+//	file | env[:VARIABLE] | command:<command> | keyring[:service/account] | vault:<path>[#field] | nopasswd
+func ParseElevationCredentialsSource(spec string) (*ElevationCredentialsSource, error) {
+	kind, value, _ := strings.Cut(spec, ":")
+	source := &ElevationCredentialsSource{Kind: CredentialsSourceKind(kind)}
+	switch source.Kind {
+	case CREDENTIALS_SOURCE_FILE, CREDENTIALS_SOURCE_NOPASSWD:
+	case CREDENTIALS_SOURCE_ENV:
+		source.Variable = value
+	case CREDENTIALS_SOURCE_COMMAND:
+		source.Command = value
+	case CREDENTIALS_SOURCE_KEYRING:
+		if value != "" {
+			service, account, ok := strings.Cut(value, "/")
+			if !ok {
+				return nil, fmt.Errorf("invalid keyring specification '%s' - expected service/account", value)
+			}
+			source.Service, source.Account = service, account
+		}
+	case CREDENTIALS_SOURCE_VAULT:
+		source.Path, source.Field, _ = strings.Cut(value, "#")
+		// remember the address so unattended runs do not depend on the environment
+		source.Address = os.Getenv(DefaultVaultAddressEnv)
+	}
+	if err := source.Validate(); err != nil {
+		return nil, err
+	}
+	return source, nil
+}
+
+const maxElevationPasswordAttempts = 3
+
+var (
+	ErrNoElevationCredentials = errors.New("no elevate credentials found")
+
+	// promptElevationPassword asks for the password protecting elevate.enc.json, replaceable in tests
+	promptElevationPassword = func(app string) (string, error) {
+		return util.PromptPassword(fmt.Sprintf("Enter password to unlock credentials for elevation (%s):", app))
+	}
+)
+
+func fileExists(path string) bool {
+	_, err := os.Stat(path)
+	return !os.IsNotExist(err)
+}
+
+func (config *RemoteConfiguration) readElevationCredentialsFile() (*RemoteElevateCredentials, error) {
+	encPath := filepath.Join(config.ElevationCredentialsDirectory, ElevationCredentialsEncFile)
+	plainPath := filepath.Join(config.ElevationCredentialsDirectory, ElevationCredentialsFile)
+
+	var data []byte
+	var err error
+	switch {
+	case fileExists(encPath):
+		data, err = config.decryptElevationCredentialsFile(encPath)
+	case fileExists(plainPath):
+		data, err = os.ReadFile(plainPath)
+	default:
+		return nil, ErrNoElevationCredentials
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var credentials RemoteElevateCredentials
+	if err := json.Unmarshal(data, &credentials); err != nil {
+		return nil, err
+	}
+	return &credentials, nil
+}
+
+func (config *RemoteConfiguration) decryptElevationCredentialsFile(encPath string) ([]byte, error) {
+	encFileData, err := os.ReadFile(encPath)
+	if err != nil {
+		return nil, err
+	}
+
+	var password string
+	var decData []byte
+	var isLegacy bool
+	// decData may be nil even on success (empty plaintext), so success is tracked explicitly
+	decrypted := false
+	// try to decrypt with cached password
+	for _, cachedPassword := range elevationCredentialsPasswordCache {
+		decData, isLegacy, err = util.DecryptWithPassword(cachedPassword, encFileData)
+		if err == nil {
+			password, decrypted = cachedPassword, true
+			break
+		}
+	}
+
+	for attempt := 1; !decrypted; attempt++ {
+		password, err = promptElevationPassword(config.App)
+		if err != nil {
+			return nil, err
+		}
+		decData, isLegacy, err = util.DecryptWithPassword(password, encFileData)
+		switch {
+		case err == nil:
+			decrypted = true
+		case errors.Is(err, util.ErrDecryptionFailed) && attempt < maxElevationPasswordAttempts:
+			log.Warn("Wrong password, please try again.", "attempt", attempt, "max_attempts", maxElevationPasswordAttempts)
+		default:
+			return nil, err
+		}
+	}
+	if !slices.Contains(elevationCredentialsPasswordCache, password) {
+		elevationCredentialsPasswordCache = append(elevationCredentialsPasswordCache, password)
+	}
+
+	if isLegacy {
+		if err := migrateLegacyElevationCredentials(encPath, password, decData); err != nil {
+			log.Warn("failed to migrate elevation credentials to the new format", "path", encPath, "error", err.Error())
+		} else {
+			log.Info("elevation credentials migrated to the new encryption format", "path", encPath)
+		}
+	}
+	return decData, nil
+}
+
+// migrateLegacyElevationCredentials re-encrypts credentials stored in the legacy AES-CBC format
+// with the current format. The file is replaced atomically so it is never left half written.
+func migrateLegacyElevationCredentials(encPath string, password string, decData []byte) error {
+	encData, err := util.EncryptWithPassword(password, decData, util.DefaultArgon2Params())
+	if err != nil {
+		return err
+	}
+	mode := os.FileMode(0644)
+	if stat, err := os.Stat(encPath); err == nil {
+		mode = stat.Mode().Perm()
+	}
+	tmpPath := encPath + ".new"
+	if err := os.WriteFile(tmpPath, encData, mode); err != nil {
+		return err
+	}
+	if err := os.Rename(tmpPath, encPath); err != nil {
+		os.Remove(tmpPath)
+		return err
+	}
+	return nil
+}
+
+// ClearElevationCredentials removes stored elevation credentials and credentials source of the app
+func (config *RemoteConfiguration) ClearElevationCredentials() error {
+	for _, file := range []string{ElevationCredentialsEncFile, ElevationCredentialsFile} {
+		if err := os.Remove(filepath.Join(config.ElevationCredentialsDirectory, file)); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+	}
+	config.CredentialsSource = nil
+	config.ElevationCredentials = nil
+	delete(elevationCredentialsCache, config.ElevationCredentialsDirectory)
+	return nil
+}
+
+// TestElevationCredentials resolves the credentials and verifies them by elevating on the remote
+func (config *RemoteConfiguration) TestElevationCredentials() error {
+	credentials, err := config.GetElevationCredentials()
+	if err != nil {
+		return err
+	}
+	session, err := config.OpenAppRemoteSession()
+	if err != nil {
+		return fmt.Errorf("failed to open remote session - %s", err.Error())
+	}
+	defer session.Close()
+
+	command := fmt.Sprintf("tezbake execute --elevate --base64 %s", base64.StdEncoding.EncodeToString([]byte("id -u")))
+	result := system.RunSshCommand(session.sshClient, command, credentials.ToEnvMap())
+	if result.Error != nil {
+		return fmt.Errorf("failed to elevate on remote - %s", result.Error.Error())
+	}
+	if uid := strings.TrimSpace(string(result.Stdout)); result.ExitCode != 0 || uid != "0" {
+		return fmt.Errorf("failed to elevate on remote - exit code %d, uid '%s'", result.ExitCode, uid)
+	}
+	return nil
+}
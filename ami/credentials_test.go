@@ -0,0 +1,316 @@
+package ami
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/tez-capital/tezbake/util"
+)
+
+var testArgon2Params = util.Argon2Params{Time: 1, Memory: 8 * 1024, Threads: 1}
+
+func TestGetElevationCredentials(t *testing.T) {
+	stored := RemoteElevateCredentials{User: "bb", Password: "elevate"}
+	serialized, err := json.Marshal(stored)
+	if err != nil {
+		t.Fatal(err)
+	}
+	encrypted, err := util.EncryptWithPassword("unlock", serialized, testArgon2Params)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name            string
+		elevate         RemoteElevationKind
+		files           map[string][]byte
+		source          *ElevationCredentialsSource
+		cached          *RemoteElevateCredentials
+		cachedPasswords []string
+		prompts         []string
+		expected        *RemoteElevateCredentials
+		expectedPrompts int
+		expectedErr     error
+	}{
+		{
+			name:     "No elevation",
+			elevate:  REMOTE_ELEVATION_NONE,
+			expected: &RemoteElevateCredentials{Kind: REMOTE_ELEVATION_NONE},
+		},
+		{
+			name:     "Cached",
+			elevate:  REMOTE_ELEVATION_SUDO,
+			cached:   &RemoteElevateCredentials{Kind: REMOTE_ELEVATION_SUDO, Password: "cached"},
+			expected: &RemoteElevateCredentials{Kind: REMOTE_ELEVATION_SUDO, Password: "cached"},
+		},
+		{
+			name:            "Encrypted",
+			elevate:         REMOTE_ELEVATION_SUDO,
+			files:           map[string][]byte{ElevationCredentialsEncFile: encrypted},
+			prompts:         []string{"unlock"},
+			expected:        &RemoteElevateCredentials{Kind: REMOTE_ELEVATION_SUDO, User: "bb", Password: "elevate"},
+			expectedPrompts: 1,
+		},
+		{
+			name:            "Encrypted with cached password",
+			elevate:         REMOTE_ELEVATION_SUDO,
+			files:           map[string][]byte{ElevationCredentialsEncFile: encrypted},
+			cachedPasswords: []string{"other", "unlock"},
+			expected:        &RemoteElevateCredentials{Kind: REMOTE_ELEVATION_SUDO, User: "bb", Password: "elevate"},
+		},
+		{
+			name:            "Encrypted preferred over plaintext",
+			elevate:         REMOTE_ELEVATION_SUDO,
+			files:           map[string][]byte{ElevationCredentialsEncFile: encrypted, ElevationCredentialsFile: []byte(`{"password":"plain"}`)},
+			prompts:         []string{"unlock"},
+			expected:        &RemoteElevateCredentials{Kind: REMOTE_ELEVATION_SUDO, User: "bb", Password: "elevate"},
+			expectedPrompts: 1,
+		},
+		{
+			name:     "Plaintext",
+			elevate:  REMOTE_ELEVATION_SU,
+			files:    map[string][]byte{ElevationCredentialsFile: serialized},
+			expected: &RemoteElevateCredentials{Kind: REMOTE_ELEVATION_SU, User: "bb", Password: "elevate"},
+		},
+		{
+			name:     "Source preferred over files",
+			elevate:  REMOTE_ELEVATION_SUDO,
+			source:   &ElevationCredentialsSource{Kind: CREDENTIALS_SOURCE_ENV, Variable: "TEST_ELEVATION_PASSWORD"},
+			files:    map[string][]byte{ElevationCredentialsFile: serialized},
+			expected: &RemoteElevateCredentials{Kind: REMOTE_ELEVATION_SUDO, Password: "env"},
+		},
+		{
+			name:        "None",
+			elevate:     REMOTE_ELEVATION_SUDO,
+			expectedErr: ErrNoElevationCredentials,
+		},
+		{
+			name:            "Wrong password retry",
+			elevate:         REMOTE_ELEVATION_SUDO,
+			files:           map[string][]byte{ElevationCredentialsEncFile: encrypted},
+			prompts:         []string{"wrong", "unlock"},
+			expected:        &RemoteElevateCredentials{Kind: REMOTE_ELEVATION_SUDO, User: "bb", Password: "elevate"},
+			expectedPrompts: 2,
+		},
+		{
+			name:            "Wrong password attempts exhausted",
+			elevate:         REMOTE_ELEVATION_SUDO,
+			files:           map[string][]byte{ElevationCredentialsEncFile: encrypted},
+			prompts:         []string{"wrong", "wrong", "wrong", "unlock"},
+			expectedPrompts: maxElevationPasswordAttempts,
+			expectedErr:     util.ErrDecryptionFailed,
+		},
+	}
+
+	t.Setenv("TEST_ELEVATION_PASSWORD", "env")
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			for name, content := range tt.files {
+				if err := os.WriteFile(filepath.Join(dir, name), content, 0600); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			elevationCredentialsCache = map[string]*RemoteElevateCredentials{}
+			if tt.cached != nil {
+				elevationCredentialsCache[dir] = tt.cached
+			}
+			elevationCredentialsPasswordCache = append([]string{}, tt.cachedPasswords...)
+
+			prompts := 0
+			originalPrompt := promptElevationPassword
+			defer func() { promptElevationPassword = originalPrompt }()
+			promptElevationPassword = func(string) (string, error) {
+				if prompts >= len(tt.prompts) {
+					return "", errors.New("unexpected password prompt")
+				}
+				prompts++
+				return tt.prompts[prompts-1], nil
+			}
+
+			config := &RemoteConfiguration{App: "node", ElevationCredentialsDirectory: dir, Elevate: tt.elevate, CredentialsSource: tt.source}
+			credentials, err := config.GetElevationCredentials()
+			if prompts != tt.expectedPrompts {
+				t.Errorf("expected %d prompts, got %d", tt.expectedPrompts, prompts)
+			}
+			if tt.expectedErr != nil {
+				if !errors.Is(err, tt.expectedErr) {
+					t.Fatalf("expected error %v, got %v", tt.expectedErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if *credentials != *tt.expected {
+				t.Errorf("expected %+v, got %+v", *tt.expected, *credentials)
+			}
+		})
+	}
+}
+
+func TestDecryptEmptyElevationCredentialsFile(t *testing.T) {
+	encrypted, err := util.EncryptWithPassword("unlock", []byte{}, testArgon2Params)
+	if err != nil {
+		t.Fatal(err)
+	}
+	encPath := filepath.Join(t.TempDir(), ElevationCredentialsEncFile)
+	if err := os.WriteFile(encPath, encrypted, 0600); err != nil {
+		t.Fatal(err)
+	}
+	elevationCredentialsPasswordCache = []string{}
+
+	prompts := 0
+	originalPrompt := promptElevationPassword
+	defer func() { promptElevationPassword = originalPrompt }()
+	promptElevationPassword = func(string) (string, error) {
+		prompts++
+		return "unlock", nil
+	}
+
+	config := &RemoteConfiguration{App: "node"}
+	data, err := config.decryptElevationCredentialsFile(encPath)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(data) != 0 || prompts != 1 {
+		t.Errorf("expected empty data after 1 prompt, got %q after %d prompts", data, prompts)
+	}
+}
+
+func TestElevationCredentialsSources(t *testing.T) {
+	vault := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("X-Vault-Token") != "token" {
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+		switch r.URL.Path {
+		case "/v1/secret/data/bakery/node":
+			w.Write([]byte(`{"data":{"data":{"password":"vault-v2"},"metadata":{}}}`))
+		case "/v1/kv/bakery/node":
+			w.Write([]byte(`{"data":{"elevate":"vault-v1"}}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer vault.Close()
+
+	t.Setenv("TEST_ELEVATION_PASSWORD", "env")
+	t.Setenv("TEST_VAULT_TOKEN", "token")
+
+	tests := []struct {
+		name     string
+		source   ElevationCredentialsSource
+		expected string
+		wantErr  bool
+	}{
+		{
+			name:     "Env",
+			source:   ElevationCredentialsSource{Kind: CREDENTIALS_SOURCE_ENV, Variable: "TEST_ELEVATION_PASSWORD"},
+			expected: "env",
+		},
+		{
+			name:    "Env not set",
+			source:  ElevationCredentialsSource{Kind: CREDENTIALS_SOURCE_ENV, Variable: "TEST_ELEVATION_PASSWORD_MISSING"},
+			wantErr: true,
+		},
+		{
+			name:     "Command",
+			source:   ElevationCredentialsSource{Kind: CREDENTIALS_SOURCE_COMMAND, Command: "printf 'command\\nmetadata\\n'"},
+			expected: "command",
+		},
+		{
+			name:    "Failing command",
+			source:  ElevationCredentialsSource{Kind: CREDENTIALS_SOURCE_COMMAND, Command: "exit 1"},
+			wantErr: true,
+		},
+		{
+			name:     "Vault KV v2",
+			source:   ElevationCredentialsSource{Kind: CREDENTIALS_SOURCE_VAULT, Address: vault.URL, Path: "secret/data/bakery/node", TokenVariable: "TEST_VAULT_TOKEN"},
+			expected: "vault-v2",
+		},
+		{
+			name:     "Vault KV v1 custom field",
+			source:   ElevationCredentialsSource{Kind: CREDENTIALS_SOURCE_VAULT, Address: vault.URL, Path: "kv/bakery/node", Field: "elevate", TokenVariable: "TEST_VAULT_TOKEN"},
+			expected: "vault-v1",
+		},
+		{
+			name:    "Vault without token",
+			source:  ElevationCredentialsSource{Kind: CREDENTIALS_SOURCE_VAULT, Address: vault.URL, Path: "secret/data/bakery/node", TokenVariable: "TEST_VAULT_TOKEN_MISSING"},
+			wantErr: true,
+		},
+	}
+
+	config := &RemoteConfiguration{App: "node", Host: "127.0.0.1", Elevate: REMOTE_ELEVATION_SUDO}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			credentials, err := tt.source.GetElevationCredentials(config)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("expected error, got credentials %+v", credentials)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if credentials.Password != tt.expected || credentials.Kind != REMOTE_ELEVATION_SUDO {
+				t.Errorf("expected password %s (sudo), got %s (%s)", tt.expected, credentials.Password, credentials.Kind)
+			}
+		})
+	}
+}
+
+func TestParseElevationCredentialsSource(t *testing.T) {
+	tests := []struct {
+		spec     string
+		expected ElevationCredentialsSource
+		wantErr  bool
+	}{
+		{spec: "env", expected: ElevationCredentialsSource{Kind: CREDENTIALS_SOURCE_ENV}},
+		{spec: "env:NODE_PASSWORD", expected: ElevationCredentialsSource{Kind: CREDENTIALS_SOURCE_ENV, Variable: "NODE_PASSWORD"}},
+		{spec: "command:pass show bakery/node", expected: ElevationCredentialsSource{Kind: CREDENTIALS_SOURCE_COMMAND, Command: "pass show bakery/node"}},
+		{spec: "keyring:tezbake/node", expected: ElevationCredentialsSource{Kind: CREDENTIALS_SOURCE_KEYRING, Service: "tezbake", Account: "node"}},
+		{spec: "vault:secret/data/node#pass", expected: ElevationCredentialsSource{Kind: CREDENTIALS_SOURCE_VAULT, Path: "secret/data/node", Field: "pass"}},
+		{spec: "command", wantErr: true},
+		{spec: "keyring:tezbake", wantErr: true},
+		{spec: "unknown", wantErr: true},
+	}
+
+	t.Setenv(DefaultVaultAddressEnv, "")
+	for _, tt := range tests {
+		t.Run(tt.spec, func(t *testing.T) {
+			source, err := ParseElevationCredentialsSource(tt.spec)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("expected error, got %+v", source)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if *source != tt.expected {
+				t.Errorf("expected %+v, got %+v", tt.expected, *source)
+			}
+		})
+	}
+}
+
+func TestParseRemoteElevationKind(t *testing.T) {
+	for value, expected := range map[string]RemoteElevationKind{"": REMOTE_ELEVATION_NONE, "su": REMOTE_ELEVATION_SU, "sudo": REMOTE_ELEVATION_SUDO} {
+		kind, err := ParseRemoteElevationKind(value)
+		if err != nil || kind != expected {
+			t.Errorf("expected %q for %q, got %q (%v)", expected, value, kind, err)
+		}
+	}
+	if _, err := ParseRemoteElevationKind("sduo"); err == nil {
+		t.Errorf("expected error for unsupported kind")
+	}
+}
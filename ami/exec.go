@@ -2,6 +2,8 @@ package ami
 
 import (
 	"bufio"
+	"bytes"
+	"errors"
 	"os"
 	"os/exec"
 	"strings"
@@ -151,6 +153,29 @@ func ExecuteWithOutputChannel(workingDir string, outputChannel chan<- string, ar
 	return runAmiCmdWithOutputChannel(workingDir, outputChannel, args...)
 }
 
+// ExecuteWithInput runs ami with input written to its stdin instead of the terminal, supported only for local apps
+func ExecuteWithInput(workingDir string, input []byte, args ...string) (exitCode int, err error) {
+	if isRemote, _ := IsRemoteApp(workingDir); isRemote {
+		return -1, errors.New("input is not supported for remote apps")
+	}
+	proc, err := createAmiCmd(workingDir, args...)
+	if err != nil {
+		return -1, err
+	}
+
+	proc.Stdin = bytes.NewReader(input)
+	proc.Stdout = os.Stdout
+	proc.Stderr = os.Stderr
+	err = proc.Run()
+	if err != nil {
+		if exitError, ok := err.(*exec.ExitError); ok {
+			return exitError.ExitCode(), err
+		}
+		return -1, err
+	}
+	return 0, nil
+}
+
 func ExecuteGetOutput(workingDir string, args ...string) (output string, exitCode int, err error) {
 	if isRemote, locator := IsRemoteApp(workingDir); isRemote {
 		session, err := locator.OpenAppRemoteSession()
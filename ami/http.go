@@ -0,0 +1,32 @@
+package ami
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"time"
+)
+
+// NewAppHttpClient returns http client reaching endpoints from the app's host perspective,
+// requests of remote apps are tunneled through ssh. Returned function releases the client.
+func NewAppHttpClient(workingDir string, timeout time.Duration) (*http.Client, func(), error) {
+	transport := &http.Transport{
+		DialContext: (&net.Dialer{
+			Timeout: timeout,
+		}).DialContext,
+	}
+	closeFn := func() {}
+
+	if isRemote, locator := IsRemoteApp(workingDir); isRemote {
+		session, err := locator.OpenAppRemoteSession()
+		if err != nil {
+			return nil, closeFn, err
+		}
+		transport.DialContext = func(_ context.Context, network string, addr string) (net.Conn, error) {
+			return session.sshClient.Dial(network, addr)
+		}
+		closeFn = session.Close
+	}
+
+	return &http.Client{Transport: transport, Timeout: timeout}, closeFn, nil
+}
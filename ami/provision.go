@@ -0,0 +1,256 @@
+package ami
+
+import (
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"os"
+	"path"
+	"regexp"
+	"strings"
+
+	"github.com/tez-capital/tezbake/constants"
+	"github.com/tez-capital/tezbake/system"
+	"github.com/tez-capital/tezbake/util"
+	"go.alis.is/common/log"
+
+	"golang.org/x/crypto/ssh"
+)
+
+const (
+	SudoersDropInFile = "/etc/sudoers.d/tezbake"
+	// sshd keeps the first value it reads, the drop-in has to sort before others (e.g. 50-cloud-init.conf)
+	SshdConfigDropIn   = "/etc/ssh/sshd_config.d/00-tezbake.conf"
+	remoteTezbakePath  = "/usr/bin/tezbake"
+	provisionDepsToGet = "curl ca-certificates sudo"
+)
+
+type ProvisionOptions struct {
+	// AppDir is the local directory of the app the remote is provisioned for
+	AppDir string
+	App    string
+	// Remote is the admin login - user@host[:port]
+	Remote string
+	// Auth is pass|key:<path to key> used for the admin login
+	Auth string
+	// User is the user tezbake operates under on the remote
+	User                string
+	DisablePasswordAuth bool
+}
+
+func parseRemoteAuth(auth string) (string, []byte, error) {
+	r, _ := regexp.Compile("^key:(?P<key>.*)")
+	if r.MatchString(auth) {
+		matches := r.FindStringSubmatch(auth)
+		keyFile := matches[r.SubexpIndex("key")]
+		key, err := os.ReadFile(keyFile)
+		if err != nil {
+			return "", nil, fmt.Errorf("%s - %s", "failed to load ssh key", err.Error())
+		}
+		return system.SSH_MODE_KEY, key, nil
+	}
+	return system.SSH_MODE_PASS, []byte{}, nil
+}
+
+// shellQuote quotes value for POSIX shell
+func shellQuote(value string) string {
+	return "'" + strings.ReplaceAll(value, "'", `'"'"'`) + "'"
+}
+
+func provisionUserScript(user string, publicKey string) string {
+	return fmt.Sprintf(`set -e
+BB_USER=%[1]s
+BB_KEY=%[2]s
+
+if command -v apt-get >/dev/null 2>&1; then
+	export DEBIAN_FRONTEND=noninteractive
+	apt-get update -qq && apt-get install -y -qq %[3]s
+elif command -v dnf >/dev/null 2>&1; then
+	dnf install -y -q %[3]s
+elif command -v yum >/dev/null 2>&1; then
+	yum install -y -q %[3]s
+elif command -v apk >/dev/null 2>&1; then
+	apk add --no-cache %[3]s
+elif command -v zypper >/dev/null 2>&1; then
+	zypper --non-interactive install %[3]s
+else
+	echo "unsupported package manager, please install %[3]s manually" >&2
+	exit 1
+fi
+
+if ! id -u "$BB_USER" >/dev/null 2>&1; then
+	useradd --create-home --shell /bin/bash "$BB_USER" 2>/dev/null || adduser -D -s /bin/sh "$BB_USER"
+fi
+BB_HOME=$(getent passwd "$BB_USER" | cut -d: -f6)
+mkdir -p "$BB_HOME/.ssh"
+touch "$BB_HOME/.ssh/authorized_keys"
+grep -qxF "$BB_KEY" "$BB_HOME/.ssh/authorized_keys" || echo "$BB_KEY" >> "$BB_HOME/.ssh/authorized_keys"
+chmod 700 "$BB_HOME/.ssh"
+chmod 600 "$BB_HOME/.ssh/authorized_keys"
+chown -R "$BB_USER" "$BB_HOME/.ssh"
+
+SUDOERS_TMP=$(mktemp)
+cat > "$SUDOERS_TMP" <<EOF
+# managed by tezbake - allows $BB_USER to run tezbake as root without password
+# tezbake can run any command as root (e.g. 'tezbake execute --elevate'), so this is full root access
+Defaults:$BB_USER !requiretty
+$BB_USER ALL=(root) NOPASSWD: %[4]s
+EOF
+visudo -cf "$SUDOERS_TMP" >/dev/null
+install -m 0440 -o root "$SUDOERS_TMP" %[5]s
+rm -f "$SUDOERS_TMP"
+grep -qE '^[#@]includedir[[:space:]]+/etc/sudoers.d' /etc/sudoers || echo "warning: /etc/sudoers does not include /etc/sudoers.d" >&2
+`, shellQuote(user), shellQuote(publicKey), provisionDepsToGet, remoteTezbakePath, SudoersDropInFile)
+}
+
+func disablePasswordAuthScript() string {
+	return fmt.Sprintf(`set -e
+grep -qE '^[[:space:]]*Include[[:space:]]+/etc/ssh/sshd_config.d' /etc/ssh/sshd_config || { echo "sshd_config does not include sshd_config.d" >&2; exit 1; }
+mkdir -p /etc/ssh/sshd_config.d
+cat > %[1]s <<EOF
+# managed by tezbake
+PasswordAuthentication no
+KbdInteractiveAuthentication no
+EOF
+if ! sshd -t; then rm -f %[1]s; exit 1; fi
+if ! sshd -T 2>/dev/null | grep -qix 'passwordauthentication no'; then
+	echo "password authentication is still enabled by other sshd configuration" >&2
+	rm -f %[1]s
+	exit 1
+fi
+systemctl reload ssh 2>/dev/null || systemctl reload sshd 2>/dev/null || service ssh reload 2>/dev/null || service sshd reload
+`, SshdConfigDropIn)
+}
+
+func restorePasswordAuthScript() string {
+	return fmt.Sprintf(`rm -f %s
+systemctl reload ssh 2>/dev/null || systemctl reload sshd 2>/dev/null || service ssh reload 2>/dev/null || service sshd reload
+`, SshdConfigDropIn)
+}
+
+// runAsRoot runs the script as root - directly when logged in as root, through sudo otherwise
+func runAsRoot(client *ssh.Client, username string, sudoPassword string, script string) *system.SshCommandResult {
+	cmd := fmt.Sprintf("echo %s | base64 -d | sh", base64.StdEncoding.EncodeToString([]byte(script)))
+	if username == "root" {
+		return system.RunSshCommand(client, cmd, nil)
+	}
+	return system.RunSshCommandWithInput(client, fmt.Sprintf("sudo -S -p '' sh -c %s", shellQuote(cmd)), nil, []byte(sudoPassword+"\n"))
+}
+
+func checkScriptResult(result *system.SshCommandResult, action string) error {
+	if result.Error != nil || result.ExitCode != 0 {
+		return fmt.Errorf("failed to %s (exit code %d) - %s", action, result.ExitCode, strings.TrimSpace(string(result.Stderr)))
+	}
+	return nil
+}
+
+// verifySession opens a fresh session as the provisioned user and checks it is allowed to run tezbake as root
+func verifySession(config *RemoteConfiguration) error {
+	session, err := config.OpenAppRemoteSession()
+	if err != nil {
+		return fmt.Errorf("failed to open session as %s - %s", config.Username, err.Error())
+	}
+	defer session.Close()
+
+	result := system.RunSshCommand(session.sshClient, fmt.Sprintf("sudo -n -- %s --version", remoteTezbakePath), nil)
+	return checkScriptResult(result, "elevate tezbake as "+config.Username)
+}
+
+// ProvisionRemote prepares a fresh host for remote app - creates the tezbake user, installs the app key,
+// dependencies and tezbake, allows the user to run tezbake as root without password (which is full root
+// access, tezbake can run any command elevated) and optionally disables ssh password authentication.
+// The app locator is written only after a fresh session as the new user is verified.
+func ProvisionRemote(options *ProvisionOptions) (*RemoteConfiguration, error) {
+	if options.User == "" {
+		options.User = constants.DefaultRemoteUser
+	}
+	adminDetails := system.GetRemoteConnectionDetails(options.Remote)
+	if adminDetails.Username == options.User {
+		return nil, fmt.Errorf("admin user must differ from the provisioned user '%s'", options.User)
+	}
+	mode, key, err := parseRemoteAuth(options.Auth)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := os.MkdirAll(options.AppDir, os.ModePerm); err != nil {
+		return nil, err
+	}
+	config := &RemoteConfiguration{
+		ElevationCredentialsDirectory: options.AppDir,
+		App:                           options.App,
+		Host:                          adminDetails.Host,
+		Port:                          adminDetails.Port,
+		Username:                      options.User,
+		InstancePath:                  constants.DefaultBBDirectory,
+		Elevate:                       REMOTE_ELEVATION_SUDO,
+		CredentialsSource:             &ElevationCredentialsSource{Kind: CREDENTIALS_SOURCE_NOPASSWD},
+		PrivateKey:                    path.Join(options.AppDir, constants.PrivateKeyFile),
+		PublicKey:                     path.Join(options.AppDir, constants.PublicKeyFile),
+	}
+	if locator, err := LoadRemoteLocator(options.AppDir); err == nil {
+		config.PopulateWith(locator)
+	}
+
+	keys := GetAppKeyPair(options.AppDir, false)
+	if keys.IsNew {
+		if err := os.WriteFile(config.PublicKey, []byte(strings.TrimSpace(string(keys.PublicKey))), 0644); err != nil {
+			return nil, err
+		}
+		if err := os.WriteFile(config.PrivateKey, keys.PrivateKey, 0600); err != nil {
+			return nil, err
+		}
+	}
+
+	log.Info("Connecting to remote...", "host", adminDetails.Host, "user", adminDetails.Username)
+	client, sftp, err := system.OpenSshSessionS(adminDetails, mode, key)
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to remote - %s", err.Error())
+	}
+	defer client.Close()
+	defer sftp.Close()
+
+	if result := system.RunSshCommand(client, "uname -s", nil); strings.TrimSpace(string(result.Stdout)) != "Linux" {
+		return nil, errors.New("remote provisioning is supported only on linux hosts")
+	}
+
+	adminLocator := &RemoteConfiguration{Elevate: REMOTE_ELEVATION_NONE}
+	sudoPassword := ""
+	if adminDetails.Username != "root" {
+		sudoPassword = util.RequirePasswordE(fmt.Sprintf("Enter sudo password for %s:", adminDetails.Username), "Sudo password required!", constants.ExitInternalError)
+		adminLocator.Elevate = REMOTE_ELEVATION_SUDO
+		adminLocator.ElevationCredentials = &RemoteElevateCredentials{Kind: REMOTE_ELEVATION_SUDO, Password: sudoPassword}
+	}
+
+	log.Info("Installing dependencies and creating user...", "user", options.User)
+	result := runAsRoot(client, adminDetails.Username, sudoPassword, provisionUserScript(options.User, strings.TrimSpace(string(keys.PublicKey))))
+	if err := checkScriptResult(result, "provision user"); err != nil {
+		return nil, err
+	}
+
+	log.Info("Installing tezbake...")
+	setupTezbakeForRemote(client, sftp, adminLocator, "latest")
+
+	log.Info("Verifying session...", "user", options.User)
+	if err := verifySession(config); err != nil {
+		return nil, err
+	}
+
+	if options.DisablePasswordAuth {
+		log.Info("Disabling ssh password authentication...")
+		result := runAsRoot(client, adminDetails.Username, sudoPassword, disablePasswordAuthScript())
+		if err := checkScriptResult(result, "disable password authentication"); err != nil {
+			return nil, err
+		}
+		// the admin session stays open, so we can roll back if the new configuration locks us out
+		if err := verifySession(config); err != nil {
+			log.Warn("Session verification failed after disabling password authentication, restoring...", "error", err.Error())
+			runAsRoot(client, adminDetails.Username, sudoPassword, restorePasswordAuthScript())
+			return nil, err
+		}
+	}
+
+	locator := WriteRemoteLocator(options.AppDir, config, false)
+	log.Info("Remote provisioned!", "host", config.Host, "user", config.Username)
+	return locator, nil
+}
@@ -2,16 +2,12 @@ package ami
 
 import (
 	"context"
-	"crypto/rand"
 	"encoding/base64"
 	"encoding/json"
 	"errors"
 	"fmt"
 	"os"
 	"path"
-	"path/filepath"
-	"regexp"
-	"slices"
 	"strings"
 
 	"github.com/tez-capital/tezbake/cli"
@@ -21,6 +17,7 @@ import (
 	"github.com/tez-capital/tezbake/util"
 	"go.alis.is/common/log"
 
+	shellquote "github.com/kballard/go-shellquote"
 	"github.com/pkg/sftp"
 	"golang.org/x/crypto/ssh"
 )
@@ -50,10 +47,22 @@ const (
 	REMOTE_ELEVATION_SUDO RemoteElevationKind = "sudo"
 )
 
+// ParseRemoteElevationKind validates elevation kind, empty value means no elevation
+func ParseRemoteElevationKind(value string) (RemoteElevationKind, error) {
+	switch kind := RemoteElevationKind(value); kind {
+	case REMOTE_ELEVATION_NONE, REMOTE_ELEVATION_SU, REMOTE_ELEVATION_SUDO:
+		return kind, nil
+	default:
+		return REMOTE_ELEVATION_NONE, fmt.Errorf("unsupported elevation kind '%s' (expected su or sudo)", value)
+	}
+}
+
 type RemoteElevateCredentials struct {
 	Kind     RemoteElevationKind `json:"kind"`
 	User     string              `json:"user"`
 	Password string              `json:"password"`
+	// Restricted is set for hosts prepared by remote provision, sudo is limited to tezbake without environment
+	Restricted bool `json:"-"`
 }
 
 func (creds *RemoteElevateCredentials) ToEnvMap() *map[string]string {
@@ -67,26 +76,31 @@ func (creds *RemoteElevateCredentials) ToEnvMap() *map[string]string {
 			"ELEVATION_PASSWORD": creds.Password,
 		}
 	case REMOTE_ELEVATION_SUDO:
-		return &map[string]string{
+		env := map[string]string{
 			"ELEVATION_KIND":     string(creds.Kind),
 			"ELEVATION_PASSWORD": creds.Password,
 		}
+		if creds.Restricted {
+			env["ELEVATION_RESTRICTED"] = "true"
+		}
+		return &env
 	}
 	return &map[string]string{}
 }
 
 type RemoteConfiguration struct {
-	ElevationCredentialsDirectory string                    `json:"elevation_credentials_directory"`
-	App                           string                    `json:"app"`
-	Host                          string                    `json:"host"`
-	Username                      string                    `json:"username"`
-	LocalUsername                 string                    `json:"local_username"`
-	InstancePath                  string                    `json:"path"`
-	Elevate                       RemoteElevationKind       `json:"elevate"`
-	PrivateKey                    string                    `json:"privateKey"`
-	PublicKey                     string                    `json:"publicKey"`
-	Port                          string                    `json:"port"`
-	ElevationCredentials          *RemoteElevateCredentials `json:"-"`
+	ElevationCredentialsDirectory string                      `json:"elevation_credentials_directory"`
+	App                           string                      `json:"app"`
+	Host                          string                      `json:"host"`
+	Username                      string                      `json:"username"`
+	LocalUsername                 string                      `json:"local_username"`
+	InstancePath                  string                      `json:"path"`
+	Elevate                       RemoteElevationKind         `json:"elevate"`
+	PrivateKey                    string                      `json:"privateKey"`
+	PublicKey                     string                      `json:"publicKey"`
+	Port                          string                      `json:"port"`
+	CredentialsSource             *ElevationCredentialsSource `json:"credentials_source,omitempty"`
+	ElevationCredentials          *RemoteElevateCredentials   `json:"-"`
 }
 
 // Fills empty values with values from other config
@@ -115,82 +129,24 @@ func (config *RemoteConfiguration) GetElevationCredentials() (*RemoteElevateCred
 		return credentials, nil
 	}
 
-	encPath := filepath.Join(config.ElevationCredentialsDirectory, ElevationCredentialsEncFile)
-	plainPath := filepath.Join(config.ElevationCredentialsDirectory, ElevationCredentialsFile)
-
-	tryDecrypt := func(password string, data []byte) ([]byte, error) {
-		if len(data) < 16 {
-			return nil, errors.New("data is too short to decrypt")
-		}
-		salt := data[len(data)-16:]
-		encData := data[:len(data)-16]
-
-		key := util.PrepareAESKey(password, salt)
-		decData, err := util.DecryptAES(key, encData)
+	var credentials *RemoteElevateCredentials
+	var err error
+	if config.CredentialsSource.IsFile() {
+		credentials, err = config.readElevationCredentialsFile()
+	} else {
+		credentials, err = config.CredentialsSource.GetElevationCredentials(config)
 		if err != nil {
-			return nil, err
+			err = fmt.Errorf("failed to get elevation credentials from %s source - %s", config.CredentialsSource.Kind, err.Error())
 		}
-		return decData, nil
 	}
-
-	if _, err := os.Stat(encPath); !os.IsNotExist(err) {
-		var password string
-
-		encFileData, err := os.ReadFile(encPath)
-		if err != nil {
-			return nil, err
-		}
-
-		var decData []byte
-		// try to decrypt with cached password
-		for _, password := range elevationCredentialsPasswordCache {
-			decData, err = tryDecrypt(password, encFileData)
-			if err == nil {
-				break
-			}
-		}
-
-		if decData == nil {
-			password, err = util.PromptPassword(fmt.Sprintf("Enter password to unlock credentials for elevation (%s):", config.App))
-			if err != nil {
-				return nil, err
-			}
-			decData, err = tryDecrypt(password, encFileData)
-			if err != nil {
-				return nil, err
-			}
-		}
-		if !slices.Contains(elevationCredentialsPasswordCache, password) {
-			elevationCredentialsPasswordCache = append(elevationCredentialsPasswordCache, password)
-		}
-
-		var credentials RemoteElevateCredentials
-		if err := json.Unmarshal(decData, &credentials); err != nil {
-			return nil, err
-		}
-
-		credentials.Kind = config.Elevate
-		config.ElevationCredentials = &credentials
-		elevationCredentialsCache[config.ElevationCredentialsDirectory] = &credentials
-		return &credentials, nil
-	}
-
-	if _, err := os.Stat(encPath); !os.IsNotExist(err) {
-		data, err := os.ReadFile(plainPath)
-		if err != nil {
-			return nil, err
-		}
-
-		var credentials RemoteElevateCredentials
-		if err := json.Unmarshal(data, &credentials); err != nil {
-			return nil, err
-		}
-		config.ElevationCredentials = &credentials
-		elevationCredentialsCache[config.ElevationCredentialsDirectory] = &credentials
-		return &credentials, nil
+	if err != nil {
+		return nil, err
 	}
 
-	return nil, errors.New("no elevate credentials found")
+	credentials.Kind = config.Elevate
+	config.ElevationCredentials = credentials
+	elevationCredentialsCache[config.ElevationCredentialsDirectory] = credentials
+	return credentials, nil
 }
 
 func (config *RemoteConfiguration) ToAppKeyPair() (*AppKeyPair, error) {
@@ -261,7 +217,11 @@ func GetAppKeyPair(appDir string, rekey bool) *AppKeyPair {
 	if !rekey {
 		remoteConfiguration, err = LoadRemoteLocator(appDir)
 		if err != nil {
-			return GetNewAppKeyPair()
+			// keys may be prepared before the locator is written (see ProvisionRemote)
+			remoteConfiguration = &RemoteConfiguration{
+				PrivateKey: path.Join(appDir, constants.PrivateKeyFile),
+				PublicKey:  path.Join(appDir, constants.PublicKeyFile),
+			}
 		}
 	}
 	privateKeyPath := remoteConfiguration.PrivateKey
@@ -312,18 +272,21 @@ func WriteRemoteElevationCredentials(appDir string, config *RemoteConfiguration,
 	if password == "" {
 		elevationCredentialsFileName = ElevationCredentialsFile
 	} else {
-		salt := make([]byte, 16)
-		_, err = rand.Read(salt)
-		util.AssertE(err, "failed to generate salt")
-
-		key := util.PrepareAESKey(password, salt)
-		serializedCredentials, err = util.EncryptAES(key, serializedCredentials)
+		serializedCredentials, err = util.EncryptWithPassword(password, serializedCredentials, util.DefaultArgon2Params())
 		util.AssertE(err, "failed to encrypt credentials")
-
-		serializedCredentials = append(serializedCredentials, salt...) // append salt to the end of the file
 	}
 	credentialsPath := path.Join(appDir, elevationCredentialsFileName)
 	util.AssertEE(os.WriteFile(credentialsPath, serializedCredentials, 0644), "Failed to write remote elevation credentials!", constants.ExitIOError)
+
+	// remove the other variant so stale credentials are not picked up instead of the new ones
+	staleFileName := ElevationCredentialsFile
+	if elevationCredentialsFileName == ElevationCredentialsFile {
+		staleFileName = ElevationCredentialsEncFile
+	}
+	if err := os.Remove(path.Join(appDir, staleFileName)); err != nil && !os.IsNotExist(err) {
+		log.Warn("failed to remove stale elevation credentials", "file", staleFileName, "error", err.Error())
+	}
+	delete(elevationCredentialsCache, appDir)
 }
 
 func getRemoteArchitecture(client *ssh.Client) (string, error) {
@@ -401,8 +364,14 @@ func setupTezbakeForRemote(sshClient *ssh.Client, sftp *sftp.Client, locator *Re
 	result := system.RunSshCommand(sshClient, "chmod +x "+tmpBbCliPath, nil)
 	util.AssertE(result.Error, "Failed to activate tezbake!")
 	bbcCliDst := "/usr/bin/tezbake"
-	base64Cmd := base64.StdEncoding.EncodeToString([]byte(fmt.Sprintf("cp %s %s", tmpBbCliPath, bbcCliDst)))
-	result = system.RunPipedSshCommand(sshClient, fmt.Sprintf("%s execute --base64 %s --elevate", tmpBbCliPath, base64Cmd), credentials.ToEnvMap())
+	elevatingCli := tmpBbCliPath
+	if result := system.RunSshCommand(sshClient, "test -x "+bbcCliDst, nil); result.ExitCode == 0 {
+		// sudo may be restricted to the installed tezbake (see ProvisionRemote)
+		elevatingCli = bbcCliDst
+	}
+	// install replaces the file instead of writing into the possibly running binary
+	base64Cmd := base64.StdEncoding.EncodeToString([]byte(fmt.Sprintf("install -m 755 %s %s", tmpBbCliPath, bbcCliDst)))
+	result = system.RunPipedSshCommand(sshClient, fmt.Sprintf("%s execute --base64 %s --elevate", elevatingCli, base64Cmd), credentials.ToEnvMap())
 	util.AssertE(result.Error, "Failed to copy tezbake to sbin!")
 	util.AssertBE(result.ExitCode == 0, "Failed to copy tezbake to sbin!", constants.ExitIOError)
 
@@ -466,18 +435,11 @@ func PrepareRemote(appDir string, config *RemoteConfiguration, auth string) erro
 		}
 	}
 
-	r, _ := regexp.Compile("^key:(?P<key>.*)")
-	if r.MatchString(auth) {
-		matches := r.FindStringSubmatch(auth)
-		keyFile := matches[r.SubexpIndex("key")]
-		key, err := os.ReadFile(keyFile)
-		if err != nil {
-			return fmt.Errorf("%s - %s", "failed to load ssh key", err.Error())
-		}
-		executePreparationStage(config, system.SSH_MODE_KEY, key)
-		return nil
+	mode, key, err := parseRemoteAuth(auth)
+	if err != nil {
+		return err
 	}
-	executePreparationStage(config, system.SSH_MODE_PASS, []byte{})
+	executePreparationStage(config, mode, key)
 	return nil
 }
 
@@ -494,11 +456,11 @@ func (session *TezbakeRemoteSession) Close() {
 }
 
 func (locator *RemoteConfiguration) OpenAppRemoteSession() (*TezbakeRemoteSession, error) {
-	keys, err := locator.ToAppKeyPair()
+	privateKey, err := os.ReadFile(locator.PrivateKey)
 	if err != nil {
-		return nil, err
+		return nil, errors.Join(errors.New("failed to read private key"), err)
 	}
-	client, sftp, err := system.OpenSshSessionS(locator.ToSshConnectionDetails(), system.SSH_MODE_KEY, keys.PrivateKey)
+	client, sftp, err := system.OpenSshSessionS(locator.ToSshConnectionDetails(), system.SSH_MODE_KEY, privateKey)
 
 	return &TezbakeRemoteSession{
 		sshClient:    client,
@@ -575,6 +537,12 @@ func (session *TezbakeRemoteSession) ForwardAmiExecuteGetOutput(workingDir strin
 	return string(result.Stdout), result.ExitCode, result.Error
 }
 
+// ExecuteTezbake runs tezbake with args against the remote instance and collects its output
+func (session *TezbakeRemoteSession) ExecuteTezbake(args ...string) *system.SshCommandResult {
+	cmd := shellquote.Join(append([]string{"tezbake", "--path", session.instancePath}, args...)...)
+	return runSshCommand(session.sshClient, cmd, session.locator, system.RunSshCommand)
+}
+
 func (session *TezbakeRemoteSession) GetRemoteTezbakeVersion() (string, error) {
 	result := runSshCommand(session.sshClient, "tezbake --version", session.locator, system.RunSshCommand)
 	if result.Error != nil {
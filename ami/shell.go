@@ -0,0 +1,80 @@
+package ami
+
+import (
+	"errors"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/charmbracelet/x/term"
+	"go.alis.is/common/log"
+	"golang.org/x/crypto/ssh"
+)
+
+// OpenShell runs command in an interactive pty session on the remote and returns its exit code.
+// Local terminal is switched to raw mode for the duration of the session and window
+// size changes are forwarded to the remote.
+func (session *TezbakeRemoteSession) OpenShell(command string) (int, error) {
+	fd := os.Stdin.Fd()
+	if !term.IsTerminal(fd) {
+		return -1, errors.New("remote shell requires a terminal")
+	}
+
+	sshSession, err := session.sshClient.NewSession()
+	if err != nil {
+		return -1, err
+	}
+	defer sshSession.Close()
+
+	width, height, err := term.GetSize(fd)
+	if err != nil {
+		width, height = 80, 24
+	}
+	termType := os.Getenv("TERM")
+	if termType == "" {
+		termType = "xterm-256color"
+	}
+	modes := ssh.TerminalModes{
+		ssh.ECHO:          1,
+		ssh.TTY_OP_ISPEED: 14400,
+		ssh.TTY_OP_OSPEED: 14400,
+	}
+	if err := sshSession.RequestPty(termType, height, width, modes); err != nil {
+		return -1, err
+	}
+
+	state, err := term.MakeRaw(fd)
+	if err != nil {
+		return -1, err
+	}
+	defer term.Restore(fd, state)
+
+	resize := make(chan os.Signal, 1)
+	signal.Notify(resize, syscall.SIGWINCH)
+	defer func() {
+		signal.Stop(resize)
+		close(resize)
+	}()
+	go func() {
+		for range resize {
+			if width, height, err := term.GetSize(fd); err == nil {
+				if err := sshSession.WindowChange(height, width); err != nil {
+					log.Debug("failed to forward window size", "error", err.Error())
+				}
+			}
+		}
+	}()
+
+	sshSession.Stdin = os.Stdin
+	sshSession.Stdout = os.Stdout
+	sshSession.Stderr = os.Stderr
+	err = sshSession.Run(command)
+	var exitErr *ssh.ExitError
+	if errors.As(err, &exitErr) {
+		return exitErr.ExitStatus(), nil
+	}
+	if err != nil {
+		return -1, err
+	}
+	return 0, nil
+}
@@ -0,0 +1,203 @@
+package ami
+
+import (
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/google/uuid"
+	shellquote "github.com/kballard/go-shellquote"
+	"github.com/pkg/sftp"
+	"github.com/tez-capital/tezbake/system"
+	"go.alis.is/common/log"
+)
+
+// ResolveRemotePath resolves path relative to the remote app directory
+func (session *TezbakeRemoteSession) ResolveRemotePath(remotePath string) string {
+	if path.IsAbs(remotePath) {
+		return path.Clean(remotePath)
+	}
+	return path.Join(session.instancePath, session.locator.App, remotePath)
+}
+
+func isPermissionError(err error) bool {
+	var statusErr *sftp.StatusError
+	if errors.As(err, &statusErr) && statusErr.FxCode() == sftp.ErrSSHFxPermissionDenied {
+		return true
+	}
+	return errors.Is(err, fs.ErrPermission)
+}
+
+// executeElevatedScript runs the shell script as root through remote tezbake
+func (session *TezbakeRemoteSession) executeElevatedScript(script string) error {
+	command := base64.StdEncoding.EncodeToString([]byte(shellquote.Join("sh", "-c", script)))
+	result := runSshCommand(session.sshClient, fmt.Sprintf("tezbake execute --elevate --base64 %s", command), session.locator, system.RunSshCommand)
+	if result.Error != nil || result.ExitCode != 0 {
+		return fmt.Errorf("elevated command failed (exit code %d) - %s", result.ExitCode, strings.TrimSpace(string(result.Stderr)))
+	}
+	return nil
+}
+
+func (session *TezbakeRemoteSession) downloadFile(remotePath string, localPath string, mode fs.FileMode) error {
+	source, err := session.sftpSession.Open(remotePath)
+	if err != nil {
+		return err
+	}
+	defer source.Close()
+
+	destination, err := os.OpenFile(localPath, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, mode.Perm())
+	if err != nil {
+		return err
+	}
+	defer destination.Close()
+
+	log.Debug("downloading", "remote", remotePath, "local", localPath)
+	_, err = io.Copy(destination, source)
+	return err
+}
+
+func (session *TezbakeRemoteSession) download(remotePath string, localPath string) error {
+	stat, err := session.sftpSession.Stat(remotePath)
+	if err != nil {
+		return err
+	}
+	if !stat.IsDir() {
+		return session.downloadFile(remotePath, localPath, stat.Mode())
+	}
+
+	walker := session.sftpSession.Walk(remotePath)
+	for walker.Step() {
+		if err := walker.Err(); err != nil {
+			return err
+		}
+		relativePath := strings.TrimPrefix(strings.TrimPrefix(walker.Path(), remotePath), "/")
+		target := filepath.Join(localPath, filepath.FromSlash(relativePath))
+		if walker.Stat().IsDir() {
+			if err := os.MkdirAll(target, os.ModePerm); err != nil {
+				return err
+			}
+			continue
+		}
+		if err := session.downloadFile(walker.Path(), target, walker.Stat().Mode()); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (session *TezbakeRemoteSession) uploadFile(localPath string, remotePath string, mode fs.FileMode) error {
+	source, err := os.Open(localPath)
+	if err != nil {
+		return err
+	}
+	defer source.Close()
+
+	destination, err := session.sftpSession.OpenFile(remotePath, os.O_WRONLY|os.O_CREATE|os.O_TRUNC)
+	if err != nil {
+		return err
+	}
+	defer destination.Close()
+
+	log.Debug("uploading", "local", localPath, "remote", remotePath)
+	if _, err = io.Copy(destination, source); err != nil {
+		return err
+	}
+	return destination.Chmod(mode.Perm())
+}
+
+func (session *TezbakeRemoteSession) upload(localPath string, remotePath string) error {
+	return filepath.WalkDir(localPath, func(current string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		relativePath, err := filepath.Rel(localPath, current)
+		if err != nil {
+			return err
+		}
+		target := path.Join(remotePath, filepath.ToSlash(relativePath))
+		if entry.IsDir() {
+			return session.sftpSession.MkdirAll(target)
+		}
+		info, err := entry.Info()
+		if err != nil {
+			return err
+		}
+		return session.uploadFile(current, target, info.Mode())
+	})
+}
+
+// localTarget returns the path inside localPath if it is an existing directory
+func localTarget(localPath string, name string) string {
+	if stat, err := os.Stat(localPath); err == nil && stat.IsDir() {
+		return filepath.Join(localPath, name)
+	}
+	return localPath
+}
+
+// Pull copies remote file or directory to localPath. Paths not readable by the remote user
+// are staged through a temporary directory by elevated copy if elevation is allowed.
+func (session *TezbakeRemoteSession) Pull(remotePath string, localPath string, elevate bool) error {
+	remotePath = session.ResolveRemotePath(remotePath)
+	target := localTarget(localPath, path.Base(remotePath))
+
+	if !elevate {
+		err := session.download(remotePath, target)
+		if err == nil || !isPermissionError(err) || session.locator.Elevate == REMOTE_ELEVATION_NONE {
+			return err
+		}
+		log.Info("Permission denied, retrying with elevation...", "path", remotePath)
+	}
+
+	staging := path.Join("/tmp", "tezbake-transfer-"+uuid.NewString())
+	defer session.sftpSession.RemoveAll(staging)
+	err := session.executeElevatedScript(fmt.Sprintf("set -e; mkdir -p %[1]s; cp -a %[2]s %[1]s/; chown -R %[3]s %[1]s",
+		shellquote.Join(staging), shellquote.Join(remotePath), shellquote.Join(session.locator.Username)))
+	if err != nil {
+		return err
+	}
+	return session.download(path.Join(staging, path.Base(remotePath)), target)
+}
+
+// Push copies local file or directory to remotePath. If the remote user can not write there,
+// data are uploaded to a temporary directory and moved in place by elevated copy
+// (ownership follows the destination directory).
+func (session *TezbakeRemoteSession) Push(localPath string, remotePath string, elevate bool) error {
+	remotePath = session.ResolveRemotePath(remotePath)
+	name := filepath.Base(localPath)
+
+	if !elevate {
+		target := remotePath
+		if stat, err := session.sftpSession.Stat(remotePath); err == nil && stat.IsDir() {
+			target = path.Join(remotePath, name)
+		}
+		err := session.upload(localPath, target)
+		if err == nil || !isPermissionError(err) || session.locator.Elevate == REMOTE_ELEVATION_NONE {
+			return err
+		}
+		log.Info("Permission denied, retrying with elevation...", "path", remotePath)
+	}
+
+	staging := path.Join("/tmp", "tezbake-transfer-"+uuid.NewString())
+	defer session.sftpSession.RemoveAll(staging)
+	if err := session.sftpSession.MkdirAll(staging); err != nil {
+		return err
+	}
+	if err := session.upload(localPath, path.Join(staging, name)); err != nil {
+		return err
+	}
+	return session.executeElevatedScript(fmt.Sprintf(`set -e
+SOURCE=%[1]s
+TARGET=%[2]s
+if [ -d "$TARGET" ]; then TARGET="$TARGET"/%[3]s; fi
+PARENT=$(dirname "$TARGET")
+mkdir -p "$PARENT"
+cp -a "$SOURCE" "$TARGET"
+chown -R "$(stat -c %%u:%%g "$PARENT")" "$TARGET"
+rm -rf %[4]s`, shellquote.Join(path.Join(staging, name)), shellquote.Join(remotePath), shellquote.Join(name), shellquote.Join(staging)))
+}
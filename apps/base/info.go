@@ -3,13 +3,19 @@ package base
 import (
 	"bytes"
 	"encoding/json"
+	"fmt"
 	"strings"
+	"time"
 )
 
+// InfoSchemaVersion is version of the info json output, bump it on breaking changes of the Info types
+const InfoSchemaVersion = 1
+
 type InfoBase struct {
-	Level  string `json:"level"`
-	Status string `json:"status"`
-	Output string `json:"output"`
+	Level    string `json:"level"`
+	Status   string `json:"status"`
+	Output   string `json:"output"`
+	IsRemote bool   `json:"is_remote"`
 }
 
 func GenerateFailedInfo(output string, err error) InfoBase {
@@ -20,6 +26,17 @@ func GenerateFailedInfo(output string, err error) InfoBase {
 	}
 }
 
+// InfoStatusTimedOut is status of apps which did not provide info within the deadline
+const InfoStatusTimedOut = "timed_out"
+
+func GenerateTimedOutInfo(deadline time.Duration) InfoBase {
+	return InfoBase{
+		Level:  "error",
+		Status: InfoStatusTimedOut,
+		Output: fmt.Sprintf("info was not collected within %s", deadline),
+	}
+}
+
 func ParseInfoOutput[TInfo any](infoBytes []byte) (TInfo, error) {
 	info := string(infoBytes)
 	lines := strings.Split(info, "\n")
@@ -21,6 +21,7 @@ type SetupContext struct {
 	RemoteAuth            string
 	RemoteElevate         ami.RemoteElevationKind
 	RemoteElevatePassword string
+	RemoteElevateSource   *ami.ElevationCredentialsSource
 	RemoteReset           bool
 
 	Dal bool
@@ -38,6 +39,7 @@ func (ctx *SetupContext) ToRemoteConfiguration(app BakeBuddyApp) *ami.RemoteConf
 		Port:                          connectionDetails.Port,
 		InstancePath:                  constants.DefaultBBDirectory,
 		Elevate:                       ctx.RemoteElevate,
+		CredentialsSource:             ctx.RemoteElevateSource,
 		PrivateKey:                    path.Join(app.GetPath(), constants.PrivateKeyFile),
 		PublicKey:                     path.Join(app.GetPath(), constants.PublicKeyFile),
 	}
@@ -25,6 +25,8 @@ type BakeBuddyApp interface {
 	GetInfo(optionsJson []byte) (any, error)
 	GetServiceInfo() (map[string]AmiServiceInfo, error)
 	PrintInfo(optionsJson []byte) error
+	// RenderInfo prints info collected by GetInfo
+	RenderInfo(info any, optionsJson []byte) error
 	GetVersions(options ami.CollectVersionsOptions) (*ami.InstanceVersions, error)
 	GetVersion() (string, error)
 	IsInstalled() bool
@@ -50,6 +52,9 @@ type AmiWalletInfo struct {
 	Pkh          string `json:"pkh,omitempty"`
 	Status       string `json:"status,omitempty"`
 	Endpoint     string `json:"endpoint,omitempty"`
+	// tezsign
+	Firmware string `json:"firmware,omitempty"`
+	Locked   bool   `json:"locked,omitempty"`
 }
 
 type BBInstanceVersions struct {
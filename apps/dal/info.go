@@ -6,9 +6,11 @@ import (
 	"os"
 	"reflect"
 	"strings"
+	"time"
 
 	"github.com/tez-capital/tezbake/ami"
 	"github.com/tez-capital/tezbake/apps/base"
+	"go.alis.is/common/log"
 
 	"github.com/jedib0t/go-pretty/v6/table"
 	"github.com/jedib0t/go-pretty/v6/text"
@@ -20,7 +22,7 @@ type Info struct {
 	Type             string                         `json:"type"`
 	Version          string                         `json:"version"`
 	AttesterProfiles []string                       `json:"attester_profiles"`
-	IsRemote         bool                           `json:"isRemote"`
+	Stats            *Stats                         `json:"stats,omitempty"`
 }
 
 func (i *Info) UnmarshalJSON(data []byte) error {
@@ -46,6 +48,8 @@ type InfoCollectionOptions struct {
 	Timeout  int
 	Services bool
 	Dal      bool
+	// Stats are collected by tezbake through rpc, not by ami, only on request (--dal-stats)
+	Stats bool
 }
 
 func (infoCollectionOptions *InfoCollectionOptions) toAmiArgs() []string {
@@ -98,11 +102,20 @@ func (app *DalNode) GetInfoFromOptions(options *InfoCollectionOptions) (Info, er
 	}
 
 	info, err := base.ParseInfoOutput[Info](infoBytes)
-	info.IsRemote, _ = ami.IsRemoteApp(app.GetPath())
+	info.IsRemote = app.IsRemoteApp()
 	if err != nil {
 		return Info{InfoBase: base.GenerateFailedInfo(string(infoBytes), err)}, err
 	}
-	return info, err
+
+	// stats require many rpc calls (and a tunnel for remote apps), so they are collected only on request
+	if options.Stats {
+		timeout := time.Duration(max(options.Timeout, 5)) * time.Second
+		info.Stats, err = app.collectStats(info.AttesterProfiles, timeout)
+		if err != nil {
+			log.Warn("Failed to collect dal stats!", "error", err.Error())
+		}
+	}
+	return info, nil
 }
 
 func (app *DalNode) GetInfo(optionsJson []byte) (any, error) {
@@ -133,6 +146,10 @@ func (app *DalNode) PrintInfo(optionsJson []byte) error {
 	if err != nil {
 		return err
 	}
+	return app.RenderInfo(dalInfoRaw, optionsJson)
+}
+
+func (app *DalNode) RenderInfo(dalInfoRaw any, optionsJson []byte) error {
 	dalInfo, ok := dalInfoRaw.(Info)
 	if !ok {
 		return fmt.Errorf("invalid tezpay info type")
@@ -158,6 +175,10 @@ func (app *DalNode) PrintInfo(optionsJson []byte) error {
 	}
 	dalTable.AppendSeparator()
 
+	if dalInfo.Stats != nil {
+		printStats(dalTable, dalInfo.Stats)
+	}
+
 	dalTable.AppendSeparator()
 	dalTable.AppendRow(table.Row{"Services", "Services"}, table.RowConfig{AutoMerge: true})
 	dalTable.AppendSeparator()
@@ -170,3 +191,42 @@ func (app *DalNode) PrintInfo(optionsJson []byte) error {
 	dalTable.Render()
 	return nil
 }
+
+func formatAttestationRate(profile ProfileStats) string {
+	if profile.AttestationRate == nil {
+		return "-"
+	}
+	rate := fmt.Sprintf("%.2f%% (%d/%d)", *profile.AttestationRate*100, profile.AttestedSlots, profile.AttestableSlots)
+	if !profile.SufficientParticipation {
+		rate += " AT RISK"
+	}
+	return rate
+}
+
+func printStats(dalTable table.Writer, stats *Stats) {
+	dalTable.AppendSeparator()
+	dalTable.AppendRow(table.Row{"Stats", "Stats"}, table.RowConfig{AutoMerge: true})
+	dalTable.AppendSeparator()
+	nodeEndpointStatus := "unreachable"
+	if stats.NodeEndpoint.Reachable {
+		nodeEndpointStatus = "reachable"
+		if stats.NodeEndpoint.SameChain != nil && !*stats.NodeEndpoint.SameChain {
+			nodeEndpointStatus += ", DIFFERENT CHAIN"
+		}
+	}
+	dalTable.AppendRow(table.Row{"Node Endpoint", fmt.Sprintf("%s (%s)", stats.NodeEndpoint.Url, nodeEndpointStatus)})
+	dalTable.AppendRow(table.Row{"Peers", stats.Peers})
+	dalTable.AppendRow(table.Row{"Topics", stats.Topics})
+	dalTable.AppendRow(table.Row{"Published Slots", stats.PublishedSlots})
+	for _, profile := range stats.Profiles {
+		dalTable.AppendSeparator()
+		dalTable.AppendRow(table.Row{profile.Profile, profile.Profile}, table.RowConfig{AutoMerge: true})
+		dalTable.AppendRow(table.Row{"Attestation Rate", formatAttestationRate(profile)})
+		dalTable.AppendRow(table.Row{"Assigned Shards", profile.AssignedShards})
+		dalTable.AppendRow(table.Row{"Topics", profile.Topics})
+	}
+	for _, statsError := range stats.Errors {
+		dalTable.AppendRow(table.Row{"Error", statsError})
+	}
+	dalTable.AppendSeparator()
+}
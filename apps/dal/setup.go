@@ -1,7 +1,9 @@
 package dal
 
 import (
+	"errors"
 	"fmt"
+	"io/fs"
 	"os"
 	"strings"
 
@@ -32,7 +34,7 @@ func (app *DalNode) Setup(ctx *base.SetupContext, args ...string) (int, error) {
 		if err != nil {
 			return -1, fmt.Errorf("failed to create directory structure for remote node locator - %s", err.Error())
 		}
-		if !useExistingCredentials {
+		if !useExistingCredentials && config.CredentialsSource.IsFile() {
 			switch ctx.RemoteElevate {
 			case ami.REMOTE_ELEVATION_SU:
 				fallthrough
@@ -124,3 +126,21 @@ func (app *DalNode) SetAttesterProfiles(keys []string) error {
 	rawAttesterProfiles := strings.Join(keys, "\n")
 	return ami.WriteFile(app.GetPath(), []byte(rawAttesterProfiles), constants.AttesterProfilesFile)
 }
+
+// GetAttesterProfiles returns currently configured attester profiles, empty if none configured
+func (app *DalNode) GetAttesterProfiles() ([]string, error) {
+	rawAttesterProfiles, err := ami.ReadFile(app.GetPath(), constants.AttesterProfilesFile)
+	if errors.Is(err, fs.ErrNotExist) {
+		return []string{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	profiles := make([]string, 0)
+	for _, line := range strings.Split(string(rawAttesterProfiles), "\n") {
+		if profile := strings.TrimSpace(line); profile != "" {
+			profiles = append(profiles, profile)
+		}
+	}
+	return profiles, nil
+}
@@ -0,0 +1,202 @@
+package dal
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/samber/lo"
+	"github.com/tez-capital/tezbake/ami"
+	"github.com/tez-capital/tezbake/cli"
+	"github.com/tez-capital/tezbake/constants"
+	"go.alis.is/common/log"
+)
+
+type ProfileStats struct {
+	Profile string `json:"profile"`
+	// AttestedSlots and AttestableSlots are counted over the current cycle
+	AttestedSlots   int `json:"attested_slots"`
+	AttestableSlots int `json:"attestable_slots"`
+	// AttestationRate is nil until there is any attestable slot in the cycle
+	AttestationRate         *float64 `json:"attestation_rate,omitempty"`
+	SufficientParticipation bool     `json:"sufficient_participation"`
+	ExpectedRewards         string   `json:"expected_rewards,omitempty"`
+	AssignedShards          int      `json:"assigned_shards"`
+	Topics                  int      `json:"topics"`
+}
+
+type NodeEndpointStats struct {
+	Url       string `json:"url"`
+	Reachable bool   `json:"reachable"`
+	ChainId   string `json:"chain_id,omitempty"`
+	// SameChain is nil if there is no node in the instance to compare with
+	SameChain *bool `json:"same_chain,omitempty"`
+}
+
+type Stats struct {
+	Profiles []ProfileStats `json:"profiles"`
+	// PublishedSlots is the number of slots published in the head block
+	PublishedSlots int               `json:"published_slots"`
+	Topics         int               `json:"topics"`
+	Peers          int               `json:"peers"`
+	NodeEndpoint   NodeEndpointStats `json:"node_endpoint"`
+	Errors         []string          `json:"errors,omitempty"`
+}
+
+type dalParticipation struct {
+	ExpectedAssignedShardsPerSlot int    `json:"expected_assigned_shards_per_slot"`
+	DelegateAttestedDalSlots      int    `json:"delegate_attested_dal_slots"`
+	DelegateAttestableDalSlots    int    `json:"delegate_attestable_dal_slots"`
+	ExpectedDalRewards            string `json:"expected_dal_rewards"`
+	SufficientDalParticipation    bool   `json:"sufficient_dal_participation"`
+}
+
+type dalTopic struct {
+	SlotIndex int    `json:"slot_index"`
+	Pkh       string `json:"pkh"`
+}
+
+type dalShards struct {
+	Delegate string `json:"delegate"`
+	Indexes  []int  `json:"indexes"`
+}
+
+func normalizeEndpoint(endpoint string) string {
+	if endpoint != "" && !strings.HasPrefix(endpoint, "http") {
+		return "http://" + endpoint
+	}
+	return strings.TrimSuffix(endpoint, "/")
+}
+
+func rpcGet(client *http.Client, url string, result any) error {
+	log.Trace("Requesting...", "url", url)
+	response, err := client.Get(url)
+	if err != nil {
+		return err
+	}
+	defer response.Body.Close()
+	if response.StatusCode != http.StatusOK {
+		return fmt.Errorf("unexpected response status %s (%s)", response.Status, url)
+	}
+	return json.NewDecoder(response.Body).Decode(result)
+}
+
+// getInstanceNodeRpcUrl returns rpc url of the node installed in the same instance, empty if there is none
+func (app *DalNode) getInstanceNodeRpcUrl() (nodePath string, rpcUrl string) {
+	nodePath = path.Join(cli.BBdir, constants.NodeAppId)
+	if app.Path != "" {
+		nodePath = path.Join(app.Path, constants.NodeAppId)
+	}
+	// local app path, ami forwards to the remote if the node is remote
+	model, err := ami.GetAppActiveModel(nodePath)
+	if err != nil {
+		return nodePath, ""
+	}
+	rpcUrl, _ = model["LOCAL_RPC_ADDR"].(string)
+	return nodePath, normalizeEndpoint(rpcUrl)
+}
+
+func (app *DalNode) getInstanceNodeChainId(timeout time.Duration) (string, error) {
+	nodePath, rpcUrl := app.getInstanceNodeRpcUrl()
+	if rpcUrl == "" {
+		return "", nil
+	}
+	client, closeClient, err := ami.NewAppHttpClient(nodePath, timeout)
+	if err != nil {
+		return "", err
+	}
+	defer closeClient()
+	var chainId string
+	err = rpcGet(client, rpcUrl+"/chains/main/chain_id", &chainId)
+	return chainId, err
+}
+
+// collectStats collects attestation and p2p stats of the dal node through rpc of the dal node and its NODE_ENDPOINT
+func (app *DalNode) collectStats(profiles []string, timeout time.Duration) (*Stats, error) {
+	model, err := app.GetActiveModel()
+	if err != nil {
+		return nil, err
+	}
+	dalRpcUrl, _ := model["LOCAL_RPC_ADDR"].(string)
+	dalRpcUrl = normalizeEndpoint(dalRpcUrl)
+	nodeEndpoint, _ := model["NODE_ENDPOINT"].(string)
+	nodeEndpoint = normalizeEndpoint(nodeEndpoint)
+
+	client, closeClient, err := ami.NewAppHttpClient(app.GetPath(), timeout)
+	if err != nil {
+		return nil, err
+	}
+	defer closeClient()
+
+	stats := &Stats{Profiles: make([]ProfileStats, 0, len(profiles)), NodeEndpoint: NodeEndpointStats{Url: nodeEndpoint}}
+	addError := func(err error) {
+		stats.Errors = append(stats.Errors, err.Error())
+	}
+
+	var topics []dalTopic
+	if dalRpcUrl == "" {
+		addError(fmt.Errorf("dal node rpc address not found"))
+	} else {
+		if err := rpcGet(client, dalRpcUrl+"/p2p/gossipsub/topics", &topics); err != nil {
+			addError(fmt.Errorf("failed to get topics - %s", err.Error()))
+		}
+		stats.Topics = len(topics)
+		var peers []json.RawMessage
+		if err := rpcGet(client, dalRpcUrl+"/p2p/peers/list?connected", &peers); err != nil {
+			addError(fmt.Errorf("failed to get peers - %s", err.Error()))
+		}
+		stats.Peers = len(peers)
+	}
+
+	if err := rpcGet(client, nodeEndpoint+"/chains/main/chain_id", &stats.NodeEndpoint.ChainId); err != nil {
+		addError(fmt.Errorf("node endpoint is not reachable - %s", err.Error()))
+	} else {
+		stats.NodeEndpoint.Reachable = true
+		if chainId, err := app.getInstanceNodeChainId(timeout); err != nil {
+			addError(fmt.Errorf("failed to get chain id of the node - %s", err.Error()))
+		} else if chainId != "" {
+			stats.NodeEndpoint.SameChain = lo.ToPtr(chainId == stats.NodeEndpoint.ChainId)
+		}
+	}
+
+	for _, profile := range profiles {
+		profileStats := ProfileStats{
+			Profile: profile,
+			Topics:  lo.CountBy(topics, func(topic dalTopic) bool { return topic.Pkh == profile }),
+		}
+		if stats.NodeEndpoint.Reachable {
+			var participation dalParticipation
+			if err := rpcGet(client, fmt.Sprintf("%s/chains/main/blocks/head/context/delegates/%s/dal_participation", nodeEndpoint, profile), &participation); err != nil {
+				addError(fmt.Errorf("failed to get dal participation of %s - %s", profile, err.Error()))
+			} else {
+				profileStats.AttestedSlots = participation.DelegateAttestedDalSlots
+				profileStats.AttestableSlots = participation.DelegateAttestableDalSlots
+				profileStats.SufficientParticipation = participation.SufficientDalParticipation
+				profileStats.ExpectedRewards = participation.ExpectedDalRewards
+				if participation.DelegateAttestableDalSlots > 0 {
+					profileStats.AttestationRate = lo.ToPtr(float64(participation.DelegateAttestedDalSlots) / float64(participation.DelegateAttestableDalSlots))
+				}
+			}
+			var shards []dalShards
+			if err := rpcGet(client, fmt.Sprintf("%s/chains/main/blocks/head/context/dal/shards?delegates=%s", nodeEndpoint, profile), &shards); err != nil {
+				addError(fmt.Errorf("failed to get assigned shards of %s - %s", profile, err.Error()))
+			}
+			for _, assignment := range shards {
+				profileStats.AssignedShards += len(assignment.Indexes)
+			}
+		}
+		stats.Profiles = append(stats.Profiles, profileStats)
+	}
+
+	if stats.NodeEndpoint.Reachable {
+		var publishedSlots []json.RawMessage
+		if err := rpcGet(client, nodeEndpoint+"/chains/main/blocks/head/context/dal/published_slot_headers", &publishedSlots); err != nil {
+			addError(fmt.Errorf("failed to get published slots - %s", err.Error()))
+		}
+		stats.PublishedSlots = len(publishedSlots)
+	}
+	return stats, nil
+}
@@ -0,0 +1,204 @@
+package apps
+
+import (
+	"fmt"
+	"net"
+	"net/url"
+	"strings"
+
+	"github.com/samber/lo"
+	"github.com/tez-capital/tezbake/ami"
+	"github.com/tez-capital/tezbake/apps/base"
+)
+
+type LinkStatus string
+
+const (
+	LinkStatusOk LinkStatus = "ok"
+	// LinkStatusMissing - target key is not set
+	LinkStatusMissing LinkStatus = "missing"
+	// LinkStatusMismatch - target key points elsewhere than the source app
+	LinkStatusMismatch LinkStatus = "mismatch"
+	// LinkStatusDangling - target key is set but the source app is not installed
+	LinkStatusDangling LinkStatus = "dangling"
+	// LinkStatusUnresolved - expected endpoint can not be determined
+	LinkStatusUnresolved LinkStatus = "unresolved"
+	// LinkStatusSkipped - apps are not installed or target does not support the link
+	LinkStatusSkipped LinkStatus = "skipped"
+)
+
+// Link declares configuration key of the target app which should point to the endpoint of the source app
+type Link struct {
+	Target    base.BakeBuddyApp
+	TargetKey string
+	Source    base.BakeBuddyApp
+	// SourceKey is the key of the source active model holding its endpoint
+	SourceKey string
+	// Optional links are checked only if the target model already defines the key
+	Optional bool
+	// RemoveDangling removes the target key if the source app is not installed
+	RemoveDangling bool
+}
+
+// Links is the graph of endpoints shared between apps
+var Links = []Link{
+	{Target: Node, TargetKey: "DAL_NODE", Source: DalNode, SourceKey: "LOCAL_RPC_ADDR", RemoveDangling: true},
+	{Target: DalNode, TargetKey: "NODE_ENDPOINT", Source: Node, SourceKey: "LOCAL_RPC_ADDR"},
+	{Target: Signer, TargetKey: "NODE_ENDPOINT", Source: Node, SourceKey: "LOCAL_RPC_ADDR", Optional: true},
+	{Target: Peak, TargetKey: "NODE_ENDPOINT", Source: Node, SourceKey: "LOCAL_RPC_ADDR", Optional: true},
+	{Target: Peak, TargetKey: "SIGNER_ENDPOINT", Source: Signer, SourceKey: "LOCAL_RPC_ADDR", Optional: true},
+	{Target: Pay, TargetKey: "NODE_ENDPOINT", Source: Node, SourceKey: "LOCAL_RPC_ADDR", Optional: true},
+}
+
+type LinkCheck struct {
+	Link     *Link      `json:"-"`
+	Target   string     `json:"target"`
+	Key      string     `json:"key"`
+	Source   string     `json:"source"`
+	Current  string     `json:"current"`
+	Expected string     `json:"expected"`
+	Status   LinkStatus `json:"status"`
+	Message  string     `json:"message,omitempty"`
+}
+
+// NeedsUpdate tells whether applying the check changes target configuration
+func (check *LinkCheck) NeedsUpdate() bool {
+	return check.Status == LinkStatusMissing || check.Status == LinkStatusMismatch || check.Status == LinkStatusDangling
+}
+
+func normalizeEndpoint(endpoint string) string {
+	endpoint = strings.TrimSuffix(strings.TrimSpace(endpoint), "/")
+	if endpoint != "" && !strings.HasPrefix(endpoint, "http") && !strings.HasPrefix(endpoint, "tcp") {
+		endpoint = "http://" + endpoint
+	}
+	return endpoint
+}
+
+func getAppHost(app base.BakeBuddyApp) string {
+	if isRemote, locator := ami.IsRemoteApp(app.GetPath()); isRemote {
+		return locator.Host
+	}
+	return ""
+}
+
+func isLoopback(host string) bool {
+	if host == "localhost" {
+		return true
+	}
+	ip := net.ParseIP(host)
+	return ip != nil && ip.IsLoopback()
+}
+
+// resolveLinkEndpoint translates endpoint of the source app to the address reachable from the target app host
+func resolveLinkEndpoint(source base.BakeBuddyApp, target base.BakeBuddyApp, endpoint string) (string, string, error) {
+	sourceHost, targetHost := getAppHost(source), getAppHost(target)
+	if sourceHost == targetHost {
+		return endpoint, "", nil
+	}
+	if sourceHost == "" {
+		return "", "", fmt.Errorf("address of this machine reachable from %s is not known, please set it manually", targetHost)
+	}
+
+	endpointUrl, err := url.Parse(endpoint)
+	if err != nil {
+		return "", "", fmt.Errorf("invalid endpoint '%s' - %s", endpoint, err.Error())
+	}
+	message := ""
+	if isLoopback(endpointUrl.Hostname()) || endpointUrl.Hostname() == "0.0.0.0" {
+		if endpointUrl.Hostname() != "0.0.0.0" {
+			message = fmt.Sprintf("%s listens on loopback only, make sure it is reachable from other hosts", source.GetId())
+		}
+		endpointUrl.Host = net.JoinHostPort(sourceHost, endpointUrl.Port())
+	}
+	return endpointUrl.String(), message, nil
+}
+
+// ResolveEndpoint returns endpoint of the source app reachable from the target app,
+// message warns about endpoints which may not be reachable
+func (link *Link) ResolveEndpoint() (string, string, error) {
+	sourceModel, err := ami.GetAppActiveModel(link.Source.GetPath())
+	if err != nil {
+		return "", "", fmt.Errorf("failed to load %s model - %s", link.Source.GetId(), err.Error())
+	}
+	sourceEndpoint, ok := sourceModel[link.SourceKey].(string)
+	if !ok || sourceEndpoint == "" {
+		return "", "", fmt.Errorf("%s does not provide %s", link.Source.GetId(), link.SourceKey)
+	}
+	return resolveLinkEndpoint(link.Source, link.Target, normalizeEndpoint(sourceEndpoint))
+}
+
+// Check compares the target configuration with the endpoint of the source app
+func (link *Link) Check() LinkCheck {
+	check := LinkCheck{Link: link, Target: link.Target.GetId(), Key: link.TargetKey, Source: link.Source.GetId()}
+	if !link.Target.IsInstalled() {
+		check.Status, check.Message = LinkStatusSkipped, fmt.Sprintf("%s is not installed", check.Target)
+		return check
+	}
+	targetModel, err := ami.GetAppActiveModel(link.Target.GetPath())
+	if err != nil {
+		check.Status, check.Message = LinkStatusUnresolved, fmt.Sprintf("failed to load %s model - %s", check.Target, err.Error())
+		return check
+	}
+	current, hasKey := targetModel[link.TargetKey].(string)
+	if link.Optional && !hasKey {
+		check.Status, check.Message = LinkStatusSkipped, fmt.Sprintf("%s does not support %s", check.Target, link.TargetKey)
+		return check
+	}
+	check.Current = normalizeEndpoint(current)
+
+	if !link.Source.IsInstalled() {
+		check.Status = LinkStatusSkipped
+		if link.RemoveDangling && check.Current != "" {
+			check.Status, check.Message = LinkStatusDangling, fmt.Sprintf("%s is not installed", check.Source)
+		}
+		return check
+	}
+	check.Expected, check.Message, err = link.ResolveEndpoint()
+	switch {
+	case err != nil:
+		check.Status, check.Message = LinkStatusUnresolved, err.Error()
+	case check.Current == "":
+		check.Status = LinkStatusMissing
+	case check.Current != check.Expected:
+		check.Status = LinkStatusMismatch
+	default:
+		check.Status = LinkStatusOk
+	}
+	return check
+}
+
+// CheckLinks checks all links of the graph
+func CheckLinks() []LinkCheck {
+	return lo.Map(Links, func(_ Link, i int) LinkCheck { return Links[i].Check() })
+}
+
+// ApplyLinks updates target configurations and reconfigures each changed app once
+func ApplyLinks(checks []LinkCheck) error {
+	targets := lo.Uniq(lo.FilterMap(checks, func(check LinkCheck, _ int) (base.BakeBuddyApp, bool) {
+		return check.Link.Target, check.NeedsUpdate()
+	}))
+	for _, target := range targets {
+		configuration, err := target.LoadAppConfiguration()
+		if err != nil {
+			return fmt.Errorf("failed to load %s configuration - %s", target.GetId(), err.Error())
+		}
+		for _, check := range checks {
+			if check.Link.Target != target || !check.NeedsUpdate() {
+				continue
+			}
+			if check.Status == LinkStatusDangling {
+				delete(configuration, check.Key)
+			} else {
+				configuration[check.Key] = check.Expected
+			}
+		}
+		if err := ami.UpdateAppConfiguration(target.GetPath(), configuration); err != nil {
+			return fmt.Errorf("failed to update %s configuration - %s", target.GetId(), err.Error())
+		}
+		exitCode, err := target.Execute("setup", "--configure") // reconfigure to apply changes
+		if err != nil || exitCode != 0 {
+			return fmt.Errorf("failed to reconfigure %s (exit code %d)", target.GetId(), exitCode)
+		}
+	}
+	return nil
+}
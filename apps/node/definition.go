@@ -1,7 +1,6 @@
 package node
 
 import (
-	"github.com/tez-capital/tezbake/ami"
 	"github.com/tez-capital/tezbake/apps/base"
 )
 
@@ -16,18 +15,3 @@ func (app *Node) LoadAppConfiguration() (map[string]any, error) {
 func (app *Node) GetActiveModel() (map[string]any, error) {
 	return base.GetActiveModel(app)
 }
-
-func (app *Node) UpdateDalEndpoint(endpoint string) error {
-	config, err := app.LoadAppConfiguration()
-	if err != nil {
-		return err
-	}
-
-	if endpoint != "" {
-		config["DAL_NODE"] = endpoint
-	} else {
-		delete(config, "DAL_NODE")
-	}
-
-	return ami.UpdateAppConfiguration(app.GetPath(), config)
-}
@@ -26,7 +26,6 @@ type Info struct {
 	Version              string                         `json:"version"`
 	VotingCurrentPeriod  VotingCurrentPeriod            `json:"voting_current_period"`
 	VotingProposals      []any                          `json:"voting_proposals"`
-	IsRemote             bool                           `json:"isRemote"`
 }
 
 func (i *Info) UnmarshalJSON(data []byte) error {
@@ -137,7 +136,7 @@ func (app *Node) GetInfoFromOptions(options *InfoCollectionOptions) (Info, error
 	}
 
 	info, err := base.ParseInfoOutput[Info](infoBytes)
-	info.IsRemote, _ = ami.IsRemoteApp(app.GetPath())
+	info.IsRemote = app.IsRemoteApp()
 	if err != nil {
 		return Info{InfoBase: base.GenerateFailedInfo(string(infoBytes), err)}, err
 	}
@@ -172,6 +171,10 @@ func (app *Node) PrintInfo(optionsJson []byte) error {
 	if err != nil {
 		return err
 	}
+	return app.RenderInfo(nodeInfoRaw, optionsJson)
+}
+
+func (app *Node) RenderInfo(nodeInfoRaw any, optionsJson []byte) error {
 	nodeInfo, ok := nodeInfoRaw.(Info)
 	if !ok {
 		return fmt.Errorf("invalid signer info type")
@@ -0,0 +1,153 @@
+package node
+
+import (
+	"fmt"
+	"time"
+)
+
+type CycleLevels struct {
+	Cycle      int       `json:"cycle"`
+	First      int       `json:"first"`
+	Last       int       `json:"last"`
+	FirstTime  time.Time `json:"first_time"`
+	LastTime   time.Time `json:"last_time"`
+	IsComplete bool      `json:"is_complete"`
+}
+
+type MissedBlock struct {
+	Level int       `json:"level"`
+	Time  time.Time `json:"time"`
+}
+
+type MissedAttestation struct {
+	Level int       `json:"level"`
+	Time  time.Time `json:"time"`
+	Slots int       `json:"slots"`
+}
+
+type BakerPerformance struct {
+	Delegate string `json:"delegate"`
+	Cycle    int    `json:"cycle"`
+	Source   string `json:"source"`
+	// blocks are counted only for round 0 rights
+	ExpectedBlocks int           `json:"expected_blocks"`
+	BakedBlocks    int           `json:"baked_blocks"`
+	MissedBlocks   []MissedBlock `json:"missed_blocks,omitempty"`
+	// attestations are counted in slots
+	ExpectedAttestations int `json:"expected_attestations"`
+	IncludedAttestations int `json:"included_attestations"`
+	// levels of missed attestations are not provided by node rpc, they are collected from TzKT if available
+	MissedAttestations []MissedAttestation `json:"missed_attestations,omitempty"`
+	// dal attestations are nil if not available
+	DalAttestableSlots *int `json:"dal_attestable_slots,omitempty"`
+	DalAttestedSlots   *int `json:"dal_attested_slots,omitempty"`
+}
+
+type participation struct {
+	ExpectedCycleActivity int `json:"expected_cycle_activity"`
+	MissedSlots           int `json:"missed_slots"`
+}
+
+type dalCycleParticipation struct {
+	DelegateAttestedDalSlots   int `json:"delegate_attested_dal_slots"`
+	DelegateAttestableDalSlots int `json:"delegate_attestable_dal_slots"`
+}
+
+type blockHeader struct {
+	Level     int       `json:"level"`
+	Timestamp time.Time `json:"timestamp"`
+}
+
+// GetHeadCycle returns cycle of the head block
+func (rpc *RpcClient) GetHeadCycle() (int, error) {
+	var level currentLevel
+	err := rpc.Get("/chains/main/blocks/head/helpers/current_level", &level)
+	return level.Cycle, err
+}
+
+// GetCycleLevels returns first and last level of the cycle, for the current cycle last is the head
+func (rpc *RpcClient) GetCycleLevels(cycle int) (*CycleLevels, error) {
+	var head blockHeader
+	if err := rpc.Get("/chains/main/blocks/head/header", &head); err != nil {
+		return nil, fmt.Errorf("failed to get head header - %s", err.Error())
+	}
+	headCycle, err := rpc.GetHeadCycle()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get current level - %s", err.Error())
+	}
+	if cycle > headCycle {
+		return nil, fmt.Errorf("cycle %d did not start yet", cycle)
+	}
+	var levels struct {
+		First int `json:"first"`
+		Last  int `json:"last"`
+	}
+	if err := rpc.Get(fmt.Sprintf("/chains/main/blocks/head/helpers/levels_in_current_cycle?offset=%d", cycle-headCycle), &levels); err != nil {
+		return nil, fmt.Errorf("failed to get levels of cycle %d - %s", cycle, err.Error())
+	}
+	result := &CycleLevels{Cycle: cycle, First: levels.First, Last: levels.Last, IsComplete: levels.Last <= head.Level}
+	if !result.IsComplete {
+		result.Last, result.LastTime = head.Level, head.Timestamp
+	} else {
+		var last blockHeader
+		if err := rpc.Get(fmt.Sprintf("/chains/main/blocks/%d/header", result.Last), &last); err != nil {
+			return nil, fmt.Errorf("failed to get header of level %d - %s", result.Last, err.Error())
+		}
+		result.LastTime = last.Timestamp
+	}
+	var first blockHeader
+	if err := rpc.Get(fmt.Sprintf("/chains/main/blocks/%d/header", result.First), &first); err != nil {
+		return nil, fmt.Errorf("failed to get header of level %d - %s", result.First, err.Error())
+	}
+	result.FirstTime = first.Timestamp
+	return result, nil
+}
+
+// GetBakerPerformance computes baked blocks and included attestations of the delegate in the cycle,
+// the node has to keep context of the last level of the cycle. Delegate has to be resolved from consensus key,
+// block metadata and participation refer to the delegate.
+func (rpc *RpcClient) GetBakerPerformance(delegate string, levels *CycleLevels) (*BakerPerformance, error) {
+	performance := &BakerPerformance{Delegate: delegate, Cycle: levels.Cycle, Source: "node"}
+	block := fmt.Sprintf("/chains/main/blocks/%d", levels.Last)
+
+	var bakingRights []bakingRight
+	if err := rpc.Get(fmt.Sprintf("%s/helpers/baking_rights?cycle=%d&delegate=%s&max_round=0", block, levels.Cycle, delegate), &bakingRights); err != nil {
+		return nil, fmt.Errorf("failed to get baking rights - %s", err.Error())
+	}
+	for _, right := range bakingRights {
+		if right.Level > levels.Last {
+			continue
+		}
+		performance.ExpectedBlocks++
+		var metadata struct {
+			Baker string `json:"baker"`
+		}
+		if err := rpc.Get(fmt.Sprintf("/chains/main/blocks/%d/metadata", right.Level), &metadata); err != nil {
+			return nil, fmt.Errorf("failed to get metadata of level %d - %s", right.Level, err.Error())
+		}
+		if metadata.Baker == delegate {
+			performance.BakedBlocks++
+			continue
+		}
+		// estimated time is not provided for past levels, time of the block baked instead is used
+		var header blockHeader
+		if err := rpc.Get(fmt.Sprintf("/chains/main/blocks/%d/header", right.Level), &header); err != nil {
+			return nil, fmt.Errorf("failed to get header of level %d - %s", right.Level, err.Error())
+		}
+		performance.MissedBlocks = append(performance.MissedBlocks, MissedBlock{Level: right.Level, Time: header.Timestamp})
+	}
+
+	var attestations participation
+	if err := rpc.Get(fmt.Sprintf("%s/context/delegates/%s/participation", block, delegate), &attestations); err != nil {
+		return nil, fmt.Errorf("failed to get participation - %s", err.Error())
+	}
+	performance.ExpectedAttestations = attestations.ExpectedCycleActivity
+	performance.IncludedAttestations = attestations.ExpectedCycleActivity - attestations.MissedSlots
+
+	var dalAttestations dalCycleParticipation
+	if err := rpc.Get(fmt.Sprintf("%s/context/delegates/%s/dal_participation", block, delegate), &dalAttestations); err == nil {
+		performance.DalAttestableSlots = &dalAttestations.DelegateAttestableDalSlots
+		performance.DalAttestedSlots = &dalAttestations.DelegateAttestedDalSlots
+	}
+	return performance, nil
+}
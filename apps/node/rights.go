@@ -0,0 +1,137 @@
+package node
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"time"
+)
+
+const (
+	DutyKindBaking      = "baking"
+	DutyKindAttestation = "attestation"
+)
+
+type Duty struct {
+	Delegate      string    `json:"delegate"`
+	Kind          string    `json:"kind"`
+	Level         int       `json:"level"`
+	Round         int       `json:"round,omitempty"`
+	EstimatedTime time.Time `json:"estimated_time"`
+}
+
+type Rights struct {
+	HeadLevel int       `json:"head_level"`
+	HeadTime  time.Time `json:"head_time"`
+	Cycle     int       `json:"cycle"`
+	// LastLevel is the last level rights were collected for
+	LastLevel         int    `json:"last_level"`
+	MinimalBlockDelay int    `json:"minimal_block_delay"`
+	Duties            []Duty `json:"duties"`
+	// DalAttestations is the number of expected dal attestations per delegate
+	DalAttestations map[string]int `json:"dal_attestations"`
+	Errors          []string       `json:"errors,omitempty"`
+}
+
+type currentLevel struct {
+	Level         int `json:"level"`
+	Cycle         int `json:"cycle"`
+	CyclePosition int `json:"cycle_position"`
+}
+
+type chainConstants struct {
+	BlocksPerCycle    int    `json:"blocks_per_cycle"`
+	MinimalBlockDelay string `json:"minimal_block_delay"`
+}
+
+type bakingRight struct {
+	Level         int       `json:"level"`
+	Delegate      string    `json:"delegate"`
+	Round         int       `json:"round"`
+	EstimatedTime time.Time `json:"estimated_time"`
+}
+
+type attestationRight struct {
+	Level     int `json:"level"`
+	Delegates []struct {
+		Delegate string `json:"delegate"`
+	} `json:"delegates"`
+	EstimatedTime time.Time `json:"estimated_time"`
+}
+
+type dalParticipation struct {
+	ExpectedAssignedShardsPerSlot int `json:"expected_assigned_shards_per_slot"`
+}
+
+// GetRights collects baking (round 0) and attestation rights of delegates after the head for the current and following cycles
+func (rpc *RpcClient) GetRights(delegates []string, cycles int) (*Rights, error) {
+	var header struct {
+		Timestamp time.Time `json:"timestamp"`
+	}
+	if err := rpc.Get("/chains/main/blocks/head/header", &header); err != nil {
+		return nil, fmt.Errorf("failed to get head header - %s", err.Error())
+	}
+	var level currentLevel
+	if err := rpc.Get("/chains/main/blocks/head/helpers/current_level", &level); err != nil {
+		return nil, fmt.Errorf("failed to get current level - %s", err.Error())
+	}
+	var constants chainConstants
+	if err := rpc.Get("/chains/main/blocks/head/context/constants", &constants); err != nil {
+		return nil, fmt.Errorf("failed to get constants - %s", err.Error())
+	}
+	blockDelay, _ := strconv.Atoi(constants.MinimalBlockDelay)
+
+	rights := &Rights{
+		HeadLevel:         level.Level,
+		HeadTime:          header.Timestamp,
+		Cycle:             level.Cycle,
+		LastLevel:         level.Level - level.CyclePosition + cycles*constants.BlocksPerCycle - 1,
+		MinimalBlockDelay: blockDelay,
+		Duties:            make([]Duty, 0),
+		DalAttestations:   map[string]int{},
+	}
+	addError := func(err error) {
+		rights.Errors = append(rights.Errors, err.Error())
+	}
+
+	for cycle := level.Cycle; cycle < level.Cycle+cycles; cycle++ {
+		for _, delegate := range delegates {
+			var bakingRights []bakingRight
+			if err := rpc.Get(fmt.Sprintf("/chains/main/blocks/head/helpers/baking_rights?cycle=%d&delegate=%s&max_round=0", cycle, delegate), &bakingRights); err != nil {
+				addError(fmt.Errorf("failed to get baking rights of %s for cycle %d - %s", delegate, cycle, err.Error()))
+			}
+			for _, right := range bakingRights {
+				if right.Level > level.Level {
+					rights.Duties = append(rights.Duties, Duty{Delegate: delegate, Kind: DutyKindBaking, Level: right.Level, Round: right.Round, EstimatedTime: right.EstimatedTime})
+				}
+			}
+
+			var attestationRights []attestationRight
+			if err := rpc.Get(fmt.Sprintf("/chains/main/blocks/head/helpers/attestation_rights?cycle=%d&delegate=%s", cycle, delegate), &attestationRights); err != nil {
+				addError(fmt.Errorf("failed to get attestation rights of %s for cycle %d - %s", delegate, cycle, err.Error()))
+			}
+			for _, right := range attestationRights {
+				if right.Level > level.Level {
+					rights.Duties = append(rights.Duties, Duty{Delegate: delegate, Kind: DutyKindAttestation, Level: right.Level, EstimatedTime: right.EstimatedTime})
+				}
+			}
+		}
+	}
+
+	for _, delegate := range delegates {
+		var participation dalParticipation
+		if err := rpc.Get(fmt.Sprintf("/chains/main/blocks/head/context/delegates/%s/dal_participation", delegate), &participation); err != nil {
+			continue // dal is not active or delegate is not active
+		}
+		if participation.ExpectedAssignedShardsPerSlot > 0 {
+			for _, duty := range rights.Duties {
+				if duty.Delegate == delegate && duty.Kind == DutyKindAttestation {
+					rights.DalAttestations[delegate]++
+				}
+			}
+		}
+	}
+
+	sort.SliceStable(rights.Duties, func(i, j int) bool { return rights.Duties[i].Level < rights.Duties[j].Level })
+	return rights, nil
+}
@@ -0,0 +1,64 @@
+package node
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/tez-capital/tezbake/ami"
+	"github.com/tez-capital/tezbake/util"
+	"go.alis.is/common/log"
+)
+
+// RpcClient queries rpc of the node, for remote nodes requests are tunneled through ssh
+type RpcClient struct {
+	url         string
+	client      *http.Client
+	closeClient func()
+}
+
+func (app *Node) NewRpcClient(timeout time.Duration) (*RpcClient, error) {
+	model, err := app.GetActiveModel()
+	if err != nil {
+		return nil, fmt.Errorf("failed to load node model - %s", err.Error())
+	}
+	rpcUrl, _ := model["LOCAL_RPC_ADDR"].(string)
+	if rpcUrl == "" {
+		return nil, fmt.Errorf("node rpc address not found")
+	}
+	if !strings.HasPrefix(rpcUrl, "http") {
+		rpcUrl = "http://" + rpcUrl
+	}
+
+	client, closeClient, err := ami.NewAppHttpClient(app.GetPath(), timeout)
+	if err != nil {
+		return nil, err
+	}
+	return &RpcClient{url: strings.TrimSuffix(rpcUrl, "/"), client: client, closeClient: closeClient}, nil
+}
+
+// NewAttestationProfileResolver returns resolver of keys (consensus or companion) to delegates through rpc of the node only
+func (rpc *RpcClient) NewAttestationProfileResolver() *util.AttestationProfileResolver {
+	return &util.AttestationProfileResolver{NodeRpcUrl: rpc.url, NodeRpcClient: rpc.client}
+}
+
+func (rpc *RpcClient) Close() {
+	rpc.closeClient()
+}
+
+// Get queries rpc path and decodes the json response into result
+func (rpc *RpcClient) Get(rpcPath string, result any) error {
+	url := rpc.url + rpcPath
+	log.Trace("Requesting...", "url", url)
+	response, err := rpc.client.Get(url)
+	if err != nil {
+		return err
+	}
+	defer response.Body.Close()
+	if response.StatusCode != http.StatusOK {
+		return fmt.Errorf("unexpected response status %s (%s)", response.Status, url)
+	}
+	return json.NewDecoder(response.Body).Decode(result)
+}
@@ -31,7 +31,7 @@ func (app *Node) Setup(ctx *base.SetupContext, args ...string) (int, error) {
 		if err != nil {
 			return -1, fmt.Errorf("failed to create directory structure for remote node locator - %s", err.Error())
 		}
-		if !useExistingCredentials {
+		if !useExistingCredentials && config.CredentialsSource.IsFile() {
 			switch ctx.RemoteElevate {
 			case ami.REMOTE_ELEVATION_SU:
 				fallthrough
@@ -9,6 +9,7 @@ import (
 
 	"github.com/tez-capital/tezbake/ami"
 	"github.com/tez-capital/tezbake/apps/base"
+	"go.alis.is/common/log"
 
 	"github.com/jedib0t/go-pretty/v6/table"
 	"github.com/jedib0t/go-pretty/v6/text"
@@ -19,6 +20,14 @@ type Info struct {
 	Services map[string]base.AmiServiceInfo `json:"services"`
 	Type     string                         `json:"type"`
 	Version  string                         `json:"version"`
+	// payout status, collected with payouts option
+	LastPaidCycle int               `json:"last_paid_cycle,omitempty"`
+	PendingCycle  int               `json:"pending_cycle,omitempty"`
+	PayoutWallet  *PayoutWalletInfo `json:"payout_wallet,omitempty"`
+	FailedPayouts []Payout          `json:"failed_payouts,omitempty"`
+	// preflight of the pending cycle, collected with preflight option if payout wallet is known
+	RequiredBalance   *Mutez `json:"required_balance,omitempty"`
+	SufficientBalance *bool  `json:"sufficient_balance,omitempty"`
 }
 
 func (i *Info) UnmarshalJSON(data []byte) error {
@@ -43,10 +52,18 @@ func (i *Info) UnmarshalJSON(data []byte) error {
 type InfoCollectionOptions struct {
 	Timeout  int
 	Services bool
+	Payouts  bool
+	// Preflight generates payouts of the pending cycle to check the payout wallet balance, implies Payouts
+	Preflight bool
 }
 
+// toAmiArgs returns args of ami info, tezpay ami app collects everything by default,
+// payout status is requested only if asked for as older versions do not support it
 func (infoCollectionOptions *InfoCollectionOptions) toAmiArgs() []string {
 	args := make([]string, 0)
+	if infoCollectionOptions.Payouts || infoCollectionOptions.Preflight {
+		args = append(args, "--payouts")
+	}
 	return args
 }
 
@@ -77,6 +94,10 @@ func (app *Tezpay) GetAvailableInfoCollectionOptions() []base.AmiInfoCollectionO
 func (app *Tezpay) GetInfoFromOptions(options *InfoCollectionOptions) (Info, error) {
 	args := options.toAmiArgs()
 	infoBytes, _, err := ami.ExecuteInfo(app.GetPath(), args...)
+	if err != nil && len(args) > 0 {
+		log.Warn("Failed to collect payout status, tezpay app may not support it.", "error", err.Error())
+		infoBytes, _, err = ami.ExecuteInfo(app.GetPath())
+	}
 	if err != nil {
 		failedInfo := Info{
 			InfoBase: base.GenerateFailedInfo(string(infoBytes), err),
@@ -85,9 +106,20 @@ func (app *Tezpay) GetInfoFromOptions(options *InfoCollectionOptions) (Info, err
 	}
 
 	info, err := base.ParseInfoOutput[Info](infoBytes)
+	info.IsRemote = app.IsRemoteApp()
 	if err != nil {
 		return Info{InfoBase: base.GenerateFailedInfo(string(infoBytes), err)}, err
 	}
+
+	if options.Preflight && info.PayoutWallet != nil {
+		if blueprint, err := app.GeneratePayouts(info.PendingCycle); err != nil {
+			log.Warn("Failed to generate payouts of the pending cycle!", "error", err.Error())
+		} else {
+			required := blueprint.RequiredBalance()
+			info.RequiredBalance = &required
+			info.SufficientBalance = CheckPayoutWalletBalance(blueprint, info.PayoutWallet)
+		}
+	}
 	return info, nil
 }
 
@@ -119,6 +151,10 @@ func (app *Tezpay) PrintInfo(optionsJson []byte) error {
 	if err != nil {
 		return err
 	}
+	return app.RenderInfo(tezpayInfoRaw, optionsJson)
+}
+
+func (app *Tezpay) RenderInfo(tezpayInfoRaw any, optionsJson []byte) error {
 	tezpayInfo, ok := tezpayInfoRaw.(Info)
 	if !ok {
 		return fmt.Errorf("invalid tezpay info type")
@@ -143,6 +179,29 @@ func (app *Tezpay) PrintInfo(optionsJson []byte) error {
 		tezpayTable.AppendRow(table.Row{k, fmt.Sprintf("%v (%v)", v.Status, v.Started)})
 	}
 
+	if tezpayInfo.LastPaidCycle > 0 || tezpayInfo.PendingCycle > 0 || tezpayInfo.PayoutWallet != nil {
+		tezpayTable.AppendSeparator()
+		tezpayTable.AppendRow(table.Row{"Payouts", "Payouts"}, table.RowConfig{AutoMerge: true})
+		tezpayTable.AppendSeparator()
+		tezpayTable.AppendRow(table.Row{"Last Paid Cycle", tezpayInfo.LastPaidCycle})
+		tezpayTable.AppendRow(table.Row{"Pending Cycle", tezpayInfo.PendingCycle})
+		if tezpayInfo.PayoutWallet != nil {
+			tezpayTable.AppendRow(table.Row{"Payout Wallet", tezpayInfo.PayoutWallet.Address})
+			tezpayTable.AppendRow(table.Row{"Payout Wallet Balance", tezpayInfo.PayoutWallet.Balance})
+		}
+		if tezpayInfo.RequiredBalance != nil {
+			required := tezpayInfo.RequiredBalance.String()
+			if tezpayInfo.SufficientBalance != nil && !*tezpayInfo.SufficientBalance {
+				required += " (NOT COVERED)"
+			}
+			tezpayTable.AppendRow(table.Row{"Pending Cycle Payouts", required})
+		}
+		tezpayTable.AppendRow(table.Row{"Failed Payouts", len(tezpayInfo.FailedPayouts)})
+		for _, payout := range tezpayInfo.FailedPayouts {
+			tezpayTable.AppendRow(table.Row{fmt.Sprintf("  %d - %s", payout.Cycle, payout.Recipient), fmt.Sprintf("%v (%s)", payout.Amount, payout.Note)})
+		}
+	}
+
 	tezpayTable.Render()
 	return nil
 }
@@ -0,0 +1,117 @@
+package pay
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/samber/lo"
+	"go.alis.is/common/log"
+)
+
+// Mutez is amount in mutez, tezpay serializes amounts both as strings and numbers
+type Mutez int64
+
+func (m *Mutez) UnmarshalJSON(data []byte) error {
+	value := strings.Trim(string(data), "\"")
+	if value == "" || value == "null" {
+		*m = 0
+		return nil
+	}
+	amount, err := strconv.ParseInt(value, 10, 64)
+	if err != nil {
+		return fmt.Errorf("invalid mutez amount '%s' - %s", value, err.Error())
+	}
+	*m = Mutez(amount)
+	return nil
+}
+
+func (m Mutez) String() string {
+	sign := ""
+	if m < 0 {
+		sign, m = "-", -m
+	}
+	return fmt.Sprintf("%s%d.%06d tez", sign, m/1_000_000, m%1_000_000)
+}
+
+type PayoutWalletInfo struct {
+	Address string `json:"address"`
+	Balance Mutez  `json:"balance"`
+}
+
+type Payout struct {
+	Baker     string `json:"baker"`
+	Delegator string `json:"delegator"`
+	Recipient string `json:"recipient"`
+	Cycle     int    `json:"cycle"`
+	Kind      string `json:"kind"`
+	Amount    Mutez  `json:"amount"`
+	Fee       Mutez  `json:"fee"`
+	Valid     bool   `json:"valid"`
+	Note      string `json:"note,omitempty"`
+}
+
+type PayoutBlueprint struct {
+	Cycles  []int    `json:"cycles"`
+	Payouts []Payout `json:"payouts"`
+}
+
+// ValidPayouts returns payouts tezpay would actually send
+func (blueprint *PayoutBlueprint) ValidPayouts() []Payout {
+	return lo.Filter(blueprint.Payouts, func(payout Payout, _ int) bool { return payout.Valid })
+}
+
+// InvalidPayouts returns payouts tezpay would skip with the reason in note
+func (blueprint *PayoutBlueprint) InvalidPayouts() []Payout {
+	return lo.Filter(blueprint.Payouts, func(payout Payout, _ int) bool { return !payout.Valid })
+}
+
+// RequiredBalance returns sum of valid payouts, transaction fees are not included
+func (blueprint *PayoutBlueprint) RequiredBalance() Mutez {
+	return lo.SumBy(blueprint.ValidPayouts(), func(payout Payout) Mutez { return payout.Amount })
+}
+
+// CheckPayoutWalletBalance warns if payout wallet can not cover the generated payouts, balance is unknown if wallet is nil
+func CheckPayoutWalletBalance(blueprint *PayoutBlueprint, wallet *PayoutWalletInfo) *bool {
+	if wallet == nil {
+		log.Warn("Payout wallet balance is not known, skipping balance check.")
+		return nil
+	}
+	required := blueprint.RequiredBalance()
+	sufficient := wallet.Balance >= required
+	if !sufficient {
+		log.Warn("Payout wallet balance is not sufficient to cover payouts!", "wallet", wallet.Address, "balance", wallet.Balance.String(), "required", required.String())
+	}
+	return &sufficient
+}
+
+// GeneratePayouts generates payouts for the cycle without paying them, cycle 0 means the last completed cycle
+func (app *Tezpay) GeneratePayouts(cycle int, args ...string) (*PayoutBlueprint, error) {
+	tezpayArgs := []string{"generate-payouts", "--output-format", "json"}
+	if cycle > 0 {
+		tezpayArgs = append(tezpayArgs, "--cycle", strconv.Itoa(cycle))
+	}
+	output, exitCode, err := app.ExecuteGetOutput(append(tezpayArgs, args...)...)
+	if err != nil {
+		return nil, err
+	}
+	if exitCode != 0 {
+		return nil, fmt.Errorf("generate-payouts failed with exit code %d - %s", exitCode, strings.TrimSpace(output))
+	}
+
+	// tezpay logs to the same output, blueprint is the last json object
+	lines := strings.Split(strings.TrimSpace(output), "\n")
+	for i := len(lines) - 1; i >= 0; i-- {
+		line := strings.TrimSpace(lines[i])
+		if !strings.HasPrefix(line, "{") {
+			continue
+		}
+		blueprint := &PayoutBlueprint{}
+		if err := json.Unmarshal([]byte(line), blueprint); err != nil || (blueprint.Payouts == nil && blueprint.Cycles == nil) {
+			continue // log line
+		}
+		return blueprint, nil
+	}
+	return nil, fmt.Errorf("generate-payouts did not return payouts")
+}
@@ -0,0 +1,89 @@
+package pay
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestMutezUnmarshalJSON(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected Mutez
+		err      bool
+	}{
+		{input: `"1500000"`, expected: 1_500_000},
+		{input: `1500000`, expected: 1_500_000},
+		{input: `"-25"`, expected: -25},
+		{input: `""`, expected: 0},
+		{input: `null`, expected: 0},
+		{input: `"1.5"`, err: true},
+		{input: `"abc"`, err: true},
+	}
+	for _, tt := range tests {
+		var amount Mutez
+		err := json.Unmarshal([]byte(tt.input), &amount)
+		if tt.err {
+			if err == nil {
+				t.Errorf("%s: expected error, got %d", tt.input, amount)
+			}
+			continue
+		}
+		if err != nil || amount != tt.expected {
+			t.Errorf("%s: expected %d, got %d (%v)", tt.input, tt.expected, amount, err)
+		}
+	}
+}
+
+func TestMutezString(t *testing.T) {
+	tests := map[Mutez]string{
+		0:         "0.000000 tez",
+		1_500_000: "1.500000 tez",
+		-25:       "-0.000025 tez",
+	}
+	for amount, expected := range tests {
+		if amount.String() != expected {
+			t.Errorf("expected %s, got %s", expected, amount.String())
+		}
+	}
+}
+
+func TestPayoutBlueprint(t *testing.T) {
+	tests := []struct {
+		name     string
+		payouts  []Payout
+		valid    int
+		required Mutez
+	}{
+		{name: "Empty", payouts: nil, valid: 0, required: 0},
+		{name: "Only valid", payouts: []Payout{{Amount: 100, Valid: true}, {Amount: 250, Valid: true}}, valid: 2, required: 350},
+		{name: "Invalid skipped", payouts: []Payout{{Amount: 100, Valid: true}, {Amount: 1_000, Note: "below minimum"}}, valid: 1, required: 100},
+		{name: "Only invalid", payouts: []Payout{{Amount: 1_000}}, valid: 0, required: 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			blueprint := &PayoutBlueprint{Payouts: tt.payouts}
+			if valid := blueprint.ValidPayouts(); len(valid) != tt.valid {
+				t.Errorf("expected %d valid payouts, got %d", tt.valid, len(valid))
+			}
+			if invalid := blueprint.InvalidPayouts(); len(invalid) != len(tt.payouts)-tt.valid {
+				t.Errorf("expected %d invalid payouts, got %d", len(tt.payouts)-tt.valid, len(invalid))
+			}
+			if required := blueprint.RequiredBalance(); required != tt.required {
+				t.Errorf("expected required balance %d, got %d", tt.required, required)
+			}
+		})
+	}
+}
+
+func TestCheckPayoutWalletBalance(t *testing.T) {
+	blueprint := &PayoutBlueprint{Payouts: []Payout{{Amount: 500, Valid: true}}}
+	if sufficient := CheckPayoutWalletBalance(blueprint, nil); sufficient != nil {
+		t.Errorf("expected unknown balance check without wallet")
+	}
+	if sufficient := CheckPayoutWalletBalance(blueprint, &PayoutWalletInfo{Balance: 500}); sufficient == nil || !*sufficient {
+		t.Errorf("expected sufficient balance")
+	}
+	if sufficient := CheckPayoutWalletBalance(blueprint, &PayoutWalletInfo{Balance: 499}); sufficient == nil || *sufficient {
+		t.Errorf("expected insufficient balance")
+	}
+}
@@ -86,6 +86,7 @@ func (app *Peak) GetInfoFromOptions(options *InfoCollectionOptions) (Info, error
 		return failedInfo, fmt.Errorf("failed to collect app info (%s)", err.Error())
 	}
 	info, err := base.ParseInfoOutput[Info](infoBytes)
+	info.IsRemote = app.IsRemoteApp()
 	if err != nil {
 		return Info{InfoBase: base.GenerateFailedInfo(string(infoBytes), err)}, err
 	}
@@ -119,6 +120,10 @@ func (app *Peak) PrintInfo(optionsJson []byte) error {
 	if err != nil {
 		return err
 	}
+	return app.RenderInfo(peakInfoRaw, optionsJson)
+}
+
+func (app *Peak) RenderInfo(peakInfoRaw any, optionsJson []byte) error {
 	peakInfo, ok := peakInfoRaw.(Info)
 	if !ok {
 		return fmt.Errorf("invalid signer info type")
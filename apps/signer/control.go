@@ -17,3 +17,11 @@ func (app *Signer) Remove(all bool, args ...string) (int, error) {
 func (app *Signer) Execute(args ...string) (int, error) {
 	return ami.Execute(app.GetPath(), args...)
 }
+
+func (app *Signer) ExecuteGetOutput(args ...string) (string, int, error) {
+	return ami.ExecuteGetOutput(app.GetPath(), args...)
+}
+
+func (app *Signer) ExecuteWithInput(input []byte, args ...string) (int, error) {
+	return ami.ExecuteWithInput(app.GetPath(), input, args...)
+}
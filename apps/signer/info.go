@@ -110,6 +110,7 @@ func (app *Signer) GetInfoFromOptions(options *InfoCollectionOptions) (Info, err
 	}
 
 	info, err := base.ParseInfoOutput[Info](infoBytes)
+	info.IsRemote = app.IsRemoteApp()
 	if err != nil {
 		return Info{InfoBase: base.GenerateFailedInfo(string(infoBytes), err)}, err
 	}
@@ -143,6 +144,10 @@ func (app *Signer) PrintInfo(optionsJson []byte) error {
 	if err != nil {
 		return err
 	}
+	return app.RenderInfo(signerInfoRaw, optionsJson)
+}
+
+func (app *Signer) RenderInfo(signerInfoRaw any, optionsJson []byte) error {
 	signerInfo, ok := signerInfoRaw.(Info)
 	if !ok {
 		return fmt.Errorf("invalid signer info type")
@@ -181,9 +186,15 @@ func (app *Signer) PrintInfo(optionsJson []byte) error {
 					signerTable.AppendRow(table.Row{k, fmt.Sprintf("%v (%v) - %v", kind, pkh, status)})
 				case "tezsign":
 					status := "error"
-					if walletProperties.Authorized {
+					switch {
+					case walletProperties.Locked:
+						status = "locked"
+					case walletProperties.Authorized:
 						status = "ok"
 					}
+					if walletProperties.Firmware != "" {
+						status = fmt.Sprintf("%v (firmware %v)", status, walletProperties.Firmware)
+					}
 					signerTable.AppendRow(table.Row{k, fmt.Sprintf("%v (%v) - %v", kind, pkh, status)})
 				case "http":
 					signerTable.AppendRow(table.Row{k, fmt.Sprintf("%v (%v) - %v", walletProperties.Endpoint, pkh, walletProperties.Status)})
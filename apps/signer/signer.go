@@ -1,6 +1,7 @@
 package signer
 
 import (
+	"encoding/json"
 	"fmt"
 	"strings"
 
@@ -21,3 +22,20 @@ func (app *Signer) GetKeyHash(alias string, args ...string) (string, int, error)
 	key := strings.TrimSpace(lastLine)
 	return key, exitCode, nil
 }
+
+// RpcGet queries node rpc through the signer client and decodes the json response into result
+func (app *Signer) RpcGet(rpcPath string, result any) error {
+	output, exitCode, err := ami.ExecuteGetOutput(app.GetPath(), "client", "rpc", "get", rpcPath)
+	if err != nil {
+		return fmt.Errorf("failed to query rpc %s (%s)", rpcPath, err.Error())
+	}
+	if exitCode != 0 {
+		return fmt.Errorf("failed to query rpc %s (exit code %d)", rpcPath, exitCode)
+	}
+	// skip log lines printed before the response
+	start := strings.IndexAny(output, "{[")
+	if start < 0 {
+		return fmt.Errorf("failed to query rpc %s (unexpected output)", rpcPath)
+	}
+	return json.NewDecoder(strings.NewReader(output[start:])).Decode(result)
+}
@@ -6,6 +6,7 @@ import (
 	"strings"
 
 	"github.com/tez-capital/tezbake/apps"
+	"github.com/tez-capital/tezbake/apps/base"
 	"github.com/tez-capital/tezbake/cli"
 	"github.com/tez-capital/tezbake/constants"
 	"github.com/tez-capital/tezbake/system"
@@ -406,6 +407,8 @@ to help you choose the appropriate snapshot for your needs.`,
 		// Check if node was running and stop it before bootstrap
 		wasRunning, _ := apps.Node.IsAnyServiceStatus("running")
 		if wasRunning {
+			waitForIdleWindowIfRequested(cmd)
+			recordServiceEvent(ServiceEventBootstrapStarted, []base.BakeBuddyApp{apps.Node})
 			log.Info("Stopping node for bootstrap...")
 			exitCode, err := apps.Node.Stop()
 			util.AssertEE(err, "Failed to stop node before bootstrap", exitCode)
@@ -434,6 +437,7 @@ to help you choose the appropriate snapshot for your needs.`,
 			log.Info("Restarting node...")
 			exitCode, err = apps.Node.Start()
 			util.AssertEE(err, "Failed to restart node after bootstrap", exitCode)
+			recordServiceEvent(ServiceEventBootstrapFinished, []base.BakeBuddyApp{apps.Node})
 		}
 
 		os.Exit(exitCode)
@@ -443,5 +447,6 @@ to help you choose the appropriate snapshot for your needs.`,
 func init() {
 	bootstrapNodeCmd.Flags().Bool("no-check", false, "Bootstrap node without verifying snapshot integrity")
 	bootstrapNodeCmd.Flags().Bool("keep-snapshot", false, "Keep the snapshot file on disk after import")
+	addWhenIdleFlags(bootstrapNodeCmd)
 	RootCmd.AddCommand(bootstrapNodeCmd)
 }
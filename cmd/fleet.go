@@ -0,0 +1,208 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/samber/lo"
+	"github.com/tez-capital/tezbake/cli"
+	"github.com/tez-capital/tezbake/constants"
+	"github.com/tez-capital/tezbake/fleet"
+	"github.com/tez-capital/tezbake/util"
+
+	"github.com/jedib0t/go-pretty/v6/table"
+	"github.com/spf13/cobra"
+)
+
+func loadFleetInstances(cmd *cobra.Command) []fleet.Instance {
+	inventory, err := fleet.LoadInventory(util.GetCommandStringFlagSD(cmd, "inventory", fleet.DefaultInventoryFile))
+	util.AssertEE(err, "Failed to load fleet inventory!", constants.ExitInvalidArgs)
+
+	only, _ := cmd.Flags().GetStringSlice("only")
+	instances, err := inventory.Select(only)
+	util.AssertEE(err, "Failed to select fleet instances!", constants.ExitInvalidArgs)
+
+	err = fleet.PrepareCredentials(instances)
+	util.AssertEE(err, "Failed to prepare elevation credentials!", constants.ExitInvalidRemoteCredentials)
+	return instances
+}
+
+func getFleetConcurrency(cmd *cobra.Command) int {
+	concurrency, _ := cmd.Flags().GetInt("concurrency")
+	return concurrency
+}
+
+func summarizeFleetInfo(result *fleet.Result) string {
+	info, ok := result.Data.(map[string]any)
+	if !ok {
+		return result.Output
+	}
+	appIds := lo.Keys(info)
+	slices.Sort(appIds)
+	parts := make([]string, 0, len(appIds))
+	for _, appId := range appIds {
+		// non-app entries (e.g. schema_version) are not objects
+		appInfo, ok := info[appId].(map[string]any)
+		if !ok {
+			continue
+		}
+		summary := fmt.Sprintf("%s: %v", appId, lo.ValueOr(appInfo, "status", "unknown"))
+		if services, ok := appInfo["services"].(map[string]any); ok && len(services) > 0 {
+			running := lo.CountBy(lo.Values(services), func(service any) bool {
+				serviceInfo, _ := service.(map[string]any)
+				return serviceInfo["status"] == "running"
+			})
+			summary += fmt.Sprintf(", services %d/%d running", running, len(services))
+		}
+		if appId == "node" {
+			if bootstrapped, _ := appInfo["bootstrapped"].(bool); bootstrapped {
+				summary += ", bootstrapped"
+			}
+			if head, ok := appInfo["chain_head"].(map[string]any); ok {
+				summary += fmt.Sprintf(", level %v", head["level"])
+			}
+		}
+		parts = append(parts, summary)
+	}
+	return strings.Join(parts, "\n")
+}
+
+func summarizeFleetVersions(result *fleet.Result) string {
+	versions, ok := result.Data.(map[string]any)
+	if !ok {
+		return result.Output
+	}
+	parts := []string{fmt.Sprintf("tezbake: %v", versions["tezbake"])}
+	appIds := lo.Without(lo.Keys(versions), "tezbake")
+	slices.Sort(appIds)
+	for _, appId := range appIds {
+		appVersions, _ := versions[appId].(map[string]any)
+		packages, _ := appVersions["Packages"].(map[string]any)
+		packageIds := lo.Keys(packages)
+		slices.Sort(packageIds)
+		packageVersions := lo.Map(packageIds, func(id string, _ int) string { return fmt.Sprintf("%s@%v", id, packages[id]) })
+		parts = append(parts, fmt.Sprintf("%s: %s", appId, strings.Join(packageVersions, ", ")))
+	}
+	return strings.Join(parts, "\n")
+}
+
+func summarizeFleetOutput(result *fleet.Result) string {
+	return result.Output
+}
+
+func printFleetResults(results []*fleet.Result, summarize func(*fleet.Result) string) {
+	failed := lo.CountBy(results, func(result *fleet.Result) bool { return result.Status != fleet.StatusOk })
+
+	if cli.JsonLogFormat {
+		output, err := json.Marshal(map[string]any{
+			"results": results,
+			"failed":  failed,
+		})
+		util.AssertEE(err, "Failed to serialize fleet results!", constants.ExitSerializationFailed)
+		fmt.Println(string(output))
+	} else {
+		resultsTable := table.NewWriter()
+		resultsTable.SetOutputMirror(os.Stdout)
+		resultsTable.SetStyle(table.StyleLight)
+		resultsTable.AppendHeader(table.Row{"Alias", "Host", "Status", "Duration", "Details"})
+		for _, result := range results {
+			details := summarize(result)
+			if result.Error != "" {
+				details = strings.TrimSpace(details + "\n" + result.Error)
+			}
+			resultsTable.AppendRow(table.Row{result.Alias, result.Host, result.Status, result.Duration, details})
+			resultsTable.AppendSeparator()
+		}
+		resultsTable.Render()
+	}
+
+	if failed > 0 {
+		os.Exit(constants.ExitExternalError)
+	}
+}
+
+var fleetCmd = &cobra.Command{
+	Use:   "fleet",
+	Short: "Manages multiple BB instances.",
+	Long: `Runs tezbake commands across instances listed in the fleet inventory (fleet.hjson).
+
+Example inventory:
+{
+	defaults: { user: "bb", key: "~/.ssh/id_ed25519", elevate: "sudo", credentials_source: { kind: "nopasswd" } }
+	instances: [
+		{ alias: "baker-1", host: "10.0.0.1" }
+		{ alias: "baker-2", host: "bb@10.0.0.2:2222", path: "/bake-buddy" }
+	]
+}
+
+Arguments after -- are passed to the remote tezbake command.`,
+}
+
+var fleetInfoCmd = &cobra.Command{
+	Use:   "info [-- <info args>]",
+	Short: "Collects info from fleet instances.",
+	Run: func(cmd *cobra.Command, args []string) {
+		instances := loadFleetInstances(cmd)
+		results := fleet.Execute(instances, getFleetConcurrency(cmd), append([]string{"--output-format=json", "--log-level=error", "info"}, args...))
+		printFleetResults(results, summarizeFleetInfo)
+	},
+}
+
+var fleetVersionCmd = &cobra.Command{
+	Use:   "version",
+	Short: "Collects versions of fleet instances.",
+	Run: func(cmd *cobra.Command, args []string) {
+		instances := loadFleetInstances(cmd)
+		results := fleet.Execute(instances, getFleetConcurrency(cmd), append([]string{"--output-format=json", "--log-level=error", "version", "--all"}, args...))
+		printFleetResults(results, summarizeFleetVersions)
+	},
+}
+
+var fleetUpgradeCmd = &cobra.Command{
+	Use:   "upgrade [--rolling] [-- <upgrade args>]",
+	Short: "Upgrades fleet instances.",
+	Long:  "Upgrades fleet instances. With --rolling instances are upgraded one by one and each node has to bootstrap before the next instance is upgraded.",
+	Run: func(cmd *cobra.Command, args []string) {
+		instances := loadFleetInstances(cmd)
+		var results []*fleet.Result
+		if util.GetCommandBoolFlagS(cmd, "rolling") {
+			timeout, _ := cmd.Flags().GetDuration("bootstrap-timeout")
+			results = fleet.RollingUpgrade(instances, args, timeout)
+		} else {
+			results = fleet.Execute(instances, getFleetConcurrency(cmd), append([]string{"upgrade"}, args...))
+		}
+		printFleetResults(results, summarizeFleetOutput)
+	},
+}
+
+func newFleetServiceCmd(action string, short string) *cobra.Command {
+	return &cobra.Command{
+		Use:   fmt.Sprintf("%s [-- <%s args>]", action, action),
+		Short: short,
+		Run: func(cmd *cobra.Command, args []string) {
+			instances := loadFleetInstances(cmd)
+			results := fleet.Execute(instances, getFleetConcurrency(cmd), append([]string{action}, args...))
+			printFleetResults(results, summarizeFleetOutput)
+		},
+	}
+}
+
+func init() {
+	fleetCmd.PersistentFlags().String("inventory", fleet.DefaultInventoryFile, "Path to fleet inventory.")
+	fleetCmd.PersistentFlags().StringSlice("only", []string{}, "Aliases of instances to operate on.")
+	fleetCmd.PersistentFlags().Int("concurrency", 4, "How many instances to operate on at once.")
+
+	fleetUpgradeCmd.Flags().Bool("rolling", false, "Upgrade one instance at a time and wait for node to bootstrap.")
+	fleetUpgradeCmd.Flags().Duration("bootstrap-timeout", 30*time.Minute, "How long to wait for node to bootstrap during rolling upgrade.")
+
+	fleetCmd.AddCommand(fleetInfoCmd)
+	fleetCmd.AddCommand(fleetVersionCmd)
+	fleetCmd.AddCommand(fleetUpgradeCmd)
+	fleetCmd.AddCommand(newFleetServiceCmd("start", "Starts services of fleet instances."))
+	fleetCmd.AddCommand(newFleetServiceCmd("stop", "Stops services of fleet instances."))
+	RootCmd.AddCommand(fleetCmd)
+}
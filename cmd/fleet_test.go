@@ -0,0 +1,28 @@
+package cmd
+
+import (
+	"testing"
+
+	"github.com/tez-capital/tezbake/fleet"
+)
+
+func TestSummarizeFleetInfo(t *testing.T) {
+	result := &fleet.Result{Data: map[string]any{
+		"schema_version": float64(1),
+		"node": map[string]any{
+			"status":       "ok",
+			"bootstrapped": true,
+			"chain_head":   map[string]any{"level": float64(100)},
+			"services": map[string]any{
+				"node":  map[string]any{"status": "running"},
+				"baker": map[string]any{"status": "stopped"},
+			},
+		},
+		"signer": map[string]any{"status": "ok"},
+	}}
+
+	expected := "node: ok, services 1/2 running, bootstrapped, level 100\nsigner: ok"
+	if summary := summarizeFleetInfo(result); summary != expected {
+		t.Errorf("expected %q, got %q", expected, summary)
+	}
+}
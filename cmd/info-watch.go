@@ -0,0 +1,281 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/samber/lo"
+	"github.com/tez-capital/tezbake/apps/base"
+	"github.com/tez-capital/tezbake/apps/dal"
+	"github.com/tez-capital/tezbake/apps/node"
+	"github.com/tez-capital/tezbake/apps/pay"
+	"github.com/tez-capital/tezbake/apps/peak"
+	"github.com/tez-capital/tezbake/apps/signer"
+	"github.com/tez-capital/tezbake/constants"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/spf13/cobra"
+)
+
+type infoDashboardRow struct {
+	Label string
+	Value string
+}
+
+type infoDashboardTickMsg struct{}
+
+type infoDashboardCollectedMsg struct {
+	results []appInfoResult
+}
+
+type infoDashboardModel struct {
+	cmd      *cobra.Command
+	apps     []base.BakeBuddyApp
+	timeout  int
+	deadline time.Duration
+	interval time.Duration
+
+	results   []appInfoResult
+	values    map[string]string
+	changed   map[string]bool
+	updatedAt time.Time
+	loading   bool
+	cursor    int
+	detail    bool
+}
+
+func summarizeServices(services map[string]base.AmiServiceInfo, detailed bool) []infoDashboardRow {
+	if len(services) == 0 {
+		return nil
+	}
+	if !detailed {
+		running := lo.CountBy(lo.Values(services), func(service base.AmiServiceInfo) bool { return service.Status == "running" })
+		return []infoDashboardRow{{"Services", fmt.Sprintf("%d/%d running", running, len(services))}}
+	}
+	ids := lo.Keys(services)
+	sort.Strings(ids)
+	return lo.Map(ids, func(id string, _ int) infoDashboardRow {
+		return infoDashboardRow{"Service " + id, fmt.Sprintf("%s (%s)", services[id].Status, services[id].Started)}
+	})
+}
+
+// summarizeAppInfo turns collected app info into dashboard rows, detailed rows are shown on drill-down
+func summarizeAppInfo(info any, detailed bool) []infoDashboardRow {
+	switch info := info.(type) {
+	case node.Info:
+		rows := []infoDashboardRow{
+			{"Status", info.Status},
+			{"Head", fmt.Sprintf("%d (cycle %d)", info.ChainHead.Level, info.ChainHead.Cycle)},
+			{"Sync State", info.SyncState},
+			{"Bootstrapped", fmt.Sprint(info.Bootstrapped)},
+			{"Connections", fmt.Sprint(info.Connections)},
+			{"Voting Period", fmt.Sprintf("%s (%d remaining)", info.VotingCurrentPeriod.VotingPeriod.Kind, info.VotingCurrentPeriod.Remaining)},
+		}
+		if detailed {
+			rows = append(rows,
+				infoDashboardRow{"Protocol", info.ChainHead.Protocol},
+				infoDashboardRow{"Next Protocol", info.ChainHead.ProtocolNext},
+				infoDashboardRow{"Head Hash", info.ChainHead.Hash},
+				infoDashboardRow{"Head Timestamp", info.ChainHead.Timestamp},
+				infoDashboardRow{"Version", info.Version},
+			)
+		}
+		return append(rows, summarizeServices(info.Services, detailed)...)
+	case signer.Info:
+		rows := []infoDashboardRow{{"Status", info.Status}}
+		aliases := lo.Keys(info.Wallets)
+		sort.Strings(aliases)
+		for _, alias := range aliases {
+			wallet := info.Wallets[alias]
+			value := fmt.Sprintf("%s %s", wallet.Kind, getWalletStatus(wallet))
+			if wallet.Kind == "ledger" {
+				value = fmt.Sprintf("%s (%s)", value, wallet.LedgerStatus)
+			}
+			if detailed {
+				value = fmt.Sprintf("%s - %s", value, wallet.Pkh)
+			}
+			rows = append(rows, infoDashboardRow{"Wallet " + alias, value})
+		}
+		return append(rows, summarizeServices(info.Services, detailed)...)
+	case dal.Info:
+		rows := []infoDashboardRow{
+			{"Status", info.Status},
+			{"Attester Profiles", fmt.Sprint(len(info.AttesterProfiles))},
+		}
+		if info.Stats != nil {
+			rows = append(rows,
+				infoDashboardRow{"Peers", fmt.Sprint(info.Stats.Peers)},
+				infoDashboardRow{"Topics", fmt.Sprint(info.Stats.Topics)},
+				infoDashboardRow{"Node Endpoint", fmt.Sprintf("%s (reachable: %v)", info.Stats.NodeEndpoint.Url, info.Stats.NodeEndpoint.Reachable)},
+			)
+			for _, profile := range info.Stats.Profiles {
+				if !detailed && profile.SufficientParticipation {
+					continue
+				}
+				rate := "n/a"
+				if profile.AttestationRate != nil {
+					rate = fmt.Sprintf("%.2f%%", *profile.AttestationRate*100)
+				}
+				value := fmt.Sprintf("%d/%d attested (%s)", profile.AttestedSlots, profile.AttestableSlots, rate)
+				if !profile.SufficientParticipation {
+					value += " AT RISK"
+				}
+				rows = append(rows, infoDashboardRow{"Profile " + profile.Profile, value})
+			}
+		}
+		return append(rows, summarizeServices(info.Services, detailed)...)
+	case peak.Info:
+		return append([]infoDashboardRow{{"Status", info.Status}}, summarizeServices(info.Services, detailed)...)
+	case pay.Info:
+		rows := []infoDashboardRow{{"Status", info.Status}}
+		if info.LastPaidCycle > 0 || info.PendingCycle > 0 {
+			rows = append(rows,
+				infoDashboardRow{"Last Paid Cycle", fmt.Sprint(info.LastPaidCycle)},
+				infoDashboardRow{"Pending Cycle", fmt.Sprint(info.PendingCycle)},
+				infoDashboardRow{"Failed Payouts", fmt.Sprint(len(info.FailedPayouts))},
+			)
+		}
+		if info.PayoutWallet != nil {
+			rows = append(rows, infoDashboardRow{"Payout Wallet Balance", info.PayoutWallet.Balance.String()})
+		}
+		return append(rows, summarizeServices(info.Services, detailed)...)
+	case base.InfoBase:
+		return []infoDashboardRow{{"Status", info.Status}, {"Output", info.Output}}
+	default:
+		return []infoDashboardRow{{"Status", "unknown"}}
+	}
+}
+
+func (m infoDashboardModel) collect() tea.Cmd {
+	return func() tea.Msg {
+		return infoDashboardCollectedMsg{results: collectAppsInfo(m.cmd, m.apps, m.timeout, m.deadline)}
+	}
+}
+
+func (m infoDashboardModel) Init() tea.Cmd {
+	return m.collect()
+}
+
+func (m infoDashboardModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.KeyMsg:
+		switch msg.String() {
+		case "ctrl+c", "q":
+			return m, tea.Quit
+		case "up", "k":
+			if m.cursor > 0 {
+				m.cursor--
+			}
+		case "down", "j":
+			if m.cursor < len(m.apps)-1 {
+				m.cursor++
+			}
+		case "enter":
+			m.detail = !m.detail
+		case "esc":
+			m.detail = false
+		case "r":
+			if !m.loading {
+				m.loading = true
+				return m, m.collect()
+			}
+		}
+	case infoDashboardTickMsg:
+		if m.loading {
+			return m, nil
+		}
+		m.loading = true
+		return m, m.collect()
+	case infoDashboardCollectedMsg:
+		values := map[string]string{}
+		changed := map[string]bool{}
+		for _, result := range msg.results {
+			// changes are tracked on detailed rows so drill-down highlights them too
+			for _, row := range summarizeAppInfo(result.Info, true) {
+				key := result.App.GetId() + "/" + row.Label
+				values[key] = row.Value
+				if previous, ok := m.values[key]; ok && previous != row.Value {
+					changed[key] = true
+				}
+			}
+		}
+		m.results, m.values, m.changed = msg.results, values, changed
+		m.updatedAt, m.loading = time.Now(), false
+		return m, tea.Tick(m.interval, func(time.Time) tea.Msg { return infoDashboardTickMsg{} })
+	}
+	return m, nil
+}
+
+func (m infoDashboardModel) renderApp(s *strings.Builder, result appInfoResult, selected bool, detailed bool) {
+	cursor, style := "  ", constants.StyleNormal
+	if selected {
+		cursor, style = "▸ ", constants.StyleSelected
+	}
+	label := result.App.GetLabel()
+	if result.TimedOut {
+		label += " (timed out)"
+	} else if result.Err != nil {
+		label += " (failed)"
+	}
+	s.WriteString(fmt.Sprintf("%s%s\n", cursor, style.Render(label)))
+
+	for _, row := range summarizeAppInfo(result.Info, detailed) {
+		value := row.Value
+		if m.changed[result.App.GetId()+"/"+row.Label] {
+			value = constants.StyleHighlight.Render(value)
+		}
+		s.WriteString(fmt.Sprintf("    %s %s\n", constants.StyleDim.Render(fmt.Sprintf("%-22s", row.Label+":")), value))
+	}
+	if detailed && result.Err != nil {
+		s.WriteString(fmt.Sprintf("    %s %s\n", constants.StyleDim.Render(fmt.Sprintf("%-22s", "Error:")), result.Err.Error()))
+	}
+}
+
+func (m infoDashboardModel) View() string {
+	var s strings.Builder
+
+	s.WriteString(constants.StyleTitle.Render("🥯 TezBake Dashboard"))
+	s.WriteString("\n")
+	switch {
+	case m.results == nil:
+		s.WriteString(constants.StyleDim.Render("Collecting info..."))
+		s.WriteString("\n")
+	case m.loading:
+		s.WriteString(constants.StyleDim.Render(fmt.Sprintf("Updated %s, refreshing...", m.updatedAt.Format(time.TimeOnly))))
+		s.WriteString("\n\n")
+	default:
+		s.WriteString(constants.StyleDim.Render(fmt.Sprintf("Updated %s, refreshing every %s", m.updatedAt.Format(time.TimeOnly), m.interval)))
+		s.WriteString("\n\n")
+	}
+
+	if m.detail && m.cursor < len(m.results) {
+		m.renderApp(&s, m.results[m.cursor], true, true)
+		s.WriteString(constants.StyleHelp.Render("enter/esc: back • r: refresh • q: quit"))
+		return s.String()
+	}
+	for i, result := range m.results {
+		m.renderApp(&s, result, i == m.cursor, false)
+		s.WriteString("\n")
+	}
+	s.WriteString(constants.StyleHelp.Render("↑/↓: select app • enter: details • r: refresh • q: quit"))
+	return s.String()
+}
+
+// runInfoDashboard shows full-screen dashboard refreshing info of apps every interval
+func runInfoDashboard(cmd *cobra.Command, appsToWatch []base.BakeBuddyApp, timeout int, deadline time.Duration, interval time.Duration) error {
+	model := infoDashboardModel{
+		cmd:      cmd,
+		apps:     appsToWatch,
+		timeout:  timeout,
+		deadline: deadline,
+		interval: interval,
+		values:   map[string]string{},
+		changed:  map[string]bool{},
+		loading:  true,
+	}
+	_, err := tea.NewProgram(model, tea.WithAltScreen(), tea.WithInput(os.Stdin), tea.WithOutput(os.Stdout)).Run()
+	return err
+}
@@ -1,54 +1,194 @@
 package cmd
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
+	"os"
+	"reflect"
+	"sync"
+	"time"
 
 	"github.com/tez-capital/tezbake/apps"
+	"github.com/tez-capital/tezbake/apps/base"
+	"github.com/tez-capital/tezbake/apps/dal"
+	"github.com/tez-capital/tezbake/apps/node"
+	"github.com/tez-capital/tezbake/apps/pay"
+	"github.com/tez-capital/tezbake/apps/peak"
+	"github.com/tez-capital/tezbake/apps/signer"
 	"github.com/tez-capital/tezbake/cli"
 	"github.com/tez-capital/tezbake/constants"
+	"github.com/tez-capital/tezbake/system"
 	"github.com/tez-capital/tezbake/util"
 	"go.alis.is/common/log"
 
+	lop "github.com/samber/lo/parallel"
 	"github.com/spf13/cobra"
 )
 
+// getInfoSchema returns JSON schema of the info json output
+func getInfoSchema() map[string]any {
+	infoTypes := map[string]reflect.Type{
+		apps.Node.GetId():    reflect.TypeOf(node.Info{}),
+		apps.Signer.GetId():  reflect.TypeOf(signer.Info{}),
+		apps.DalNode.GetId(): reflect.TypeOf(dal.Info{}),
+		apps.Peak.GetId():    reflect.TypeOf(peak.Info{}),
+		apps.Pay.GetId():     reflect.TypeOf(pay.Info{}),
+	}
+	properties := map[string]any{
+		"schema_version": map[string]any{"const": base.InfoSchemaVersion},
+	}
+	for id, infoType := range infoTypes {
+		// apps which did not respond in time report only the base info with timed_out status
+		properties[id] = map[string]any{"anyOf": []any{util.JsonSchemaOf(infoType), util.JsonSchemaOf(reflect.TypeOf(base.InfoBase{}))}}
+	}
+	return map[string]any{
+		"$schema":     "https://json-schema.org/draft/2020-12/schema",
+		"title":       "tezbake info",
+		"description": fmt.Sprintf("Output of 'tezbake info -o json', schema version %d. Only info of selected or installed apps is present.", base.InfoSchemaVersion),
+		"type":        "object",
+		"properties":  properties,
+		"required":    []string{"schema_version"},
+	}
+}
+
+type appInfoResult struct {
+	App      base.BakeBuddyApp
+	Options  []byte
+	Info     any
+	Err      error
+	TimedOut bool
+}
+
+func getInfoDeadline(cmd *cobra.Command, timeout int) time.Duration {
+	deadline, _ := cmd.Flags().GetInt("deadline")
+	if deadline <= 0 {
+		deadline = timeout + 10
+	}
+	return time.Duration(deadline) * time.Second
+}
+
+func getAppInfoOptions(cmd *cobra.Command, app base.BakeBuddyApp, timeout int) []byte {
+	options := map[string]any{
+		"timeout": timeout,
+	}
+	for _, option := range app.GetAvailableInfoCollectionOptions() {
+		switch option.Type {
+		case "bool":
+			if checked, _ := cmd.Flags().GetBool(fmt.Sprintf("%s-%s", app.GetId(), option.Name)); checked {
+				options[option.Name] = true
+			}
+		}
+	}
+	// explicitly selected pay app reports payout status with the wallet balance preflight
+	if app.GetId() == apps.Pay.GetId() && util.GetCommandBoolFlagS(cmd, app.GetId()) {
+		options["payouts"] = true
+		options["preflight"] = true
+	}
+	// dashboard refreshes every interval, payout status and preflight would generate payouts on every refresh
+	if util.GetCommandBoolFlagS(cmd, "watch") {
+		delete(options, "payouts")
+		delete(options, "preflight")
+	}
+	optionsJson, _ := json.Marshal(options)
+	return optionsJson
+}
+
+// runningAppInfoCollections holds ids of apps whose info collection is still running, e.g. after it timed out.
+// New collection of such app is not started, so a hung app does not pile up ami processes on every refresh.
+var runningAppInfoCollections sync.Map
+
+// collectAppInfo collects info of the app, if it does not finish within deadline timed out info is returned
+func collectAppInfo(app base.BakeBuddyApp, optionsJson []byte, deadline time.Duration) appInfoResult {
+	timedOut := func(err error) appInfoResult {
+		info := base.GenerateTimedOutInfo(deadline)
+		info.IsRemote = app.IsRemoteApp()
+		return appInfoResult{App: app, Options: optionsJson, Info: info, Err: err, TimedOut: true}
+	}
+	if _, running := runningAppInfoCollections.LoadOrStore(app.GetId(), struct{}{}); running {
+		return timedOut(fmt.Errorf("previous info collection is still running"))
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), deadline)
+	defer cancel()
+
+	log.Debug("Collecting info for:", "app", app.GetId())
+	collected := make(chan appInfoResult, 1) // buffered so stragglers do not block after timeout
+	go func() {
+		defer runningAppInfoCollections.Delete(app.GetId())
+		info, err := app.GetInfo(optionsJson)
+		collected <- appInfoResult{App: app, Options: optionsJson, Info: info, Err: err}
+	}()
+
+	select {
+	case result := <-collected:
+		return result
+	case <-ctx.Done():
+		return timedOut(fmt.Errorf("timed out after %s", deadline))
+	}
+}
+
+// collectAppsInfo collects info of apps in parallel, results are in the order of apps
+func collectAppsInfo(cmd *cobra.Command, appsToCollectFrom []base.BakeBuddyApp, timeout int, deadline time.Duration) []appInfoResult {
+	return lop.Map(appsToCollectFrom, func(app base.BakeBuddyApp, _ int) appInfoResult {
+		return collectAppInfo(app, getAppInfoOptions(cmd, app, timeout), deadline)
+	})
+}
+
 var infoCmd = &cobra.Command{
 	Use:   "info",
 	Short: "Prints runtime information about BB.",
-	Long:  "Collects and prints runtime information about BB instance.",
+	Long: `Collects and prints runtime information about BB instance.
+
+JSON output (-o json) contains schema_version which is increased on breaking changes,
+its JSON schema is printed with --schema.
+
+With --watch a full-screen dashboard refreshes info every --interval, highlights changed values
+and shows details of the selected app on enter. Payout status is not collected in watch mode
+and an app whose previous collection is still running is reported as timed out.`,
 	Run: func(cmd *cobra.Command, args []string) {
+		if util.GetCommandBoolFlagS(cmd, "schema") {
+			output, err := json.MarshalIndent(getInfoSchema(), "", "\t")
+			util.AssertEE(err, "Failed to serialize info schema!", constants.ExitSerializationFailed)
+			fmt.Println(string(output))
+			return
+		}
+
 		timeout, _ := cmd.Flags().GetInt("timeout")
 		if timeout <= 0 {
 			timeout = 5
 		}
+		deadline := getInfoDeadline(cmd, timeout)
 
-		result := map[string]any{}
+		result := map[string]any{
+			"schema_version": base.InfoSchemaVersion,
+		}
 
-		for _, v := range GetAppsBySelectionCriteria(cmd, AppSelectionCriteria{
+		selectedApps := GetAppsBySelectionCriteria(cmd, AppSelectionCriteria{
 			InitialSelection:  InstalledApps,
 			FallbackSelection: ImplicitApps,
 			OptionCheckType:   InfoOptionCheck,
-		}) {
-			options := map[string]any{
-				"timeout": timeout,
-			}
-			for _, option := range v.GetAvailableInfoCollectionOptions() {
-				switch option.Type {
-				case "bool":
-					if checked, _ := cmd.Flags().GetBool(fmt.Sprintf("%s-%s", v.GetId(), option.Name)); checked {
-						options[option.Name] = true
-					}
-				}
-			}
+		})
+		if util.GetCommandBoolFlagS(cmd, "watch") {
+			util.AssertBE(system.IsTty(), "Watch mode requires interactive terminal!", constants.ExitNotSupported)
+			interval, _ := cmd.Flags().GetDuration("interval")
+			err := runInfoDashboard(cmd, selectedApps, timeout, deadline, max(interval, time.Second))
+			util.AssertEE(err, "Failed to run dashboard!", constants.ExitInternalError)
+			return
+		}
 
-			log.Debug("Collecting info for:", "app", v.GetId())
-			optionsJson, _ := json.Marshal(options)
+		failed := false
+		for _, appInfo := range collectAppsInfo(cmd, selectedApps, timeout, deadline) {
 			if cli.JsonLogFormat {
-				result[v.GetId()], _ = v.GetInfo(optionsJson)
-			} else {
-				err := v.PrintInfo(optionsJson)
-				util.AssertE(err, fmt.Sprintf("Failed to collect %s's info!", v.GetId()))
+				result[appInfo.App.GetId()] = appInfo.Info
+				continue
+			}
+			if appInfo.Err == nil {
+				appInfo.Err = appInfo.App.RenderInfo(appInfo.Info, appInfo.Options)
+			}
+			if appInfo.Err != nil {
+				failed = true
+				log.Error(fmt.Sprintf("Failed to collect %s's info!", appInfo.App.GetId()), "error", appInfo.Err.Error())
 			}
 		}
 
@@ -58,11 +198,18 @@ var infoCmd = &cobra.Command{
 			fmt.Println(string(output))
 			return
 		}
+		if failed {
+			os.Exit(constants.ExitExternalError)
+		}
 	},
 }
 
 func init() {
 	infoCmd.Flags().Int("timeout", 5, "How long to wait for collecting info.")
+	infoCmd.Flags().Int("deadline", 0, "How long to wait for each app before reporting it as timed out (default timeout + 10s).")
+	infoCmd.Flags().Bool("schema", false, "Prints JSON schema of the json output.")
+	infoCmd.Flags().Bool("watch", false, "Shows dashboard refreshing info of apps.")
+	infoCmd.Flags().Duration("interval", 10*time.Second, "How often to refresh info in watch mode.")
 	for _, v := range apps.All {
 		infoCmd.Flags().Bool(v.GetId(), false, fmt.Sprintf("Prints info for %s.", v.GetId()))
 		for _, option := range v.GetAvailableInfoCollectionOptions() {
@@ -0,0 +1,43 @@
+package cmd
+
+import (
+	"testing"
+
+	"github.com/tez-capital/tezbake/apps/base"
+	"github.com/tez-capital/tezbake/apps/node"
+)
+
+func TestSummarizeAppInfo(t *testing.T) {
+	info := node.Info{
+		InfoBase:    base.InfoBase{Status: "node is operational"},
+		ChainHead:   node.ChainHeadInfo{Level: 100, Cycle: 2},
+		Connections: 5,
+		Services: map[string]base.AmiServiceInfo{
+			"node":    {Status: "running"},
+			"baker":   {Status: "running"},
+			"accuser": {Status: "stopped"},
+		},
+	}
+
+	rows := summarizeAppInfo(info, false)
+	values := map[string]string{}
+	for _, row := range rows {
+		values[row.Label] = row.Value
+	}
+	if values["Head"] != "100 (cycle 2)" {
+		t.Errorf("unexpected head %q", values["Head"])
+	}
+	if values["Services"] != "2/3 running" {
+		t.Errorf("unexpected services %q", values["Services"])
+	}
+
+	detailedRows := summarizeAppInfo(info, true)
+	if len(detailedRows) <= len(rows) {
+		t.Errorf("detailed summary should have more rows")
+	}
+
+	timedOut := summarizeAppInfo(base.GenerateTimedOutInfo(0), false)
+	if timedOut[0].Value != base.InfoStatusTimedOut {
+		t.Errorf("unexpected timed out status %q", timedOut[0].Value)
+	}
+}
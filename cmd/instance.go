@@ -13,6 +13,28 @@ import (
 	"github.com/tez-capital/tezbake/constants"
 )
 
+// getInstancePrompt returns custom colorful PS1 for the shell and shell args preventing it from being overridden
+// Format: tezbake ❯ instance ❯ <alias> <pwd> ❯
+// Colors based on tez.capital branding with powerline-style arrows
+func getInstancePrompt(shellName string, alias string) (string, []string) {
+	switch shellName {
+	case "zsh":
+		// Zsh uses %F{color} for colors, %B %b for bold, %~ for pwd with ~ substitution
+		// Use -f to prevent loading .zshrc which would override PS1
+		ps1 := fmt.Sprintf("%%F{cyan}%%Btezbake%%b%%f %%F{blue}❯%%f %%F{75}instance%%f %%F{blue}❯%%f %%F{159}%%B%s%%b%%f %%F{243}%%~%%f %%F{blue}❯%%f ", alias)
+		return ps1, []string{"-f"}
+	case "bash":
+		// Bash uses \[ \] to wrap non-printing characters, \w for current directory
+		// Use --norc --noprofile to prevent loading config files
+		ps1 := fmt.Sprintf("\\[\\033[1;36m\\]tezbake\\[\\033[0m\\] \\[\\033[34m\\]❯\\[\\033[0m\\] \\[\\033[94m\\]instance\\[\\033[0m\\] \\[\\033[34m\\]❯\\[\\033[0m\\] \\[\\033[1;96m\\]%s\\[\\033[0m\\] \\[\\033[90m\\]\\w\\[\\033[0m\\] \\[\\033[34m\\]❯\\[\\033[0m\\] ", alias)
+		return ps1, []string{"--norc", "--noprofile"}
+	default:
+		// For other shells, use simple ANSI codes with $PWD
+		ps1 := fmt.Sprintf("\033[1;36mtezbake\033[0m \033[34m❯\033[0m \033[94minstance\033[0m \033[34m❯\033[0m \033[1;96m%s\033[0m \033[90m$PWD\033[0m \033[34m❯\033[0m ", alias)
+		return ps1, nil
+	}
+}
+
 // enterInstanceEnvironment spawns an interactive shell with the instance environment
 func enterInstanceEnvironment(alias, instancePath string) error {
 	shell := os.Getenv("SHELL")
@@ -42,30 +64,8 @@ func enterInstanceEnvironment(alias, instancePath string) error {
 	env := os.Environ()
 	env = append(env, fmt.Sprintf("TEZBAKE_INSTANCE_PATH=%s", instancePath))
 
-	// Set custom colorful PS1 based on shell type and determine shell args
-	// Format: tezbake ❯ instance ❯ <alias> <pwd> ❯
-	// Colors based on tez.capital branding with powerline-style arrows
-	shellName := filepath.Base(shell)
-	var shellArgs []string
-
-	switch shellName {
-	case "zsh":
-		// Zsh uses %F{color} for colors, %B %b for bold, %~ for pwd with ~ substitution
-		// Use -f to prevent loading .zshrc which would override PS1
-		ps1 := fmt.Sprintf("%%F{cyan}%%Btezbake%%b%%f %%F{blue}❯%%f %%F{75}instance%%f %%F{blue}❯%%f %%F{159}%%B%s%%b%%f %%F{243}%%~%%f %%F{blue}❯%%f ", alias)
-		env = append(env, "PS1="+ps1)
-		shellArgs = append(shellArgs, "-f")
-	case "bash":
-		// Bash uses \[ \] to wrap non-printing characters, \w for current directory
-		// Use --norc --noprofile to prevent loading config files
-		ps1 := fmt.Sprintf("\\[\\033[1;36m\\]tezbake\\[\\033[0m\\] \\[\\033[34m\\]❯\\[\\033[0m\\] \\[\\033[94m\\]instance\\[\\033[0m\\] \\[\\033[34m\\]❯\\[\\033[0m\\] \\[\\033[1;96m\\]%s\\[\\033[0m\\] \\[\\033[90m\\]\\w\\[\\033[0m\\] \\[\\033[34m\\]❯\\[\\033[0m\\] ", alias)
-		env = append(env, "PS1="+ps1)
-		shellArgs = append(shellArgs, "--norc", "--noprofile")
-	default:
-		// For other shells, use simple ANSI codes with $PWD
-		ps1 := fmt.Sprintf("\033[1;36mtezbake\033[0m \033[34m❯\033[0m \033[94minstance\033[0m \033[34m❯\033[0m \033[1;96m%s\033[0m \033[90m$PWD\033[0m \033[34m❯\033[0m ", alias)
-		env = append(env, "PS1="+ps1)
-	}
+	ps1, shellArgs := getInstancePrompt(filepath.Base(shell), alias)
+	env = append(env, "PS1="+ps1)
 
 	// Create the command with appropriate flags
 	shellCmd := exec.Command(shell, shellArgs...)
@@ -0,0 +1,248 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/samber/lo"
+	"github.com/tez-capital/tezbake/apps"
+	"github.com/tez-capital/tezbake/apps/base"
+	"github.com/tez-capital/tezbake/apps/node"
+	"github.com/tez-capital/tezbake/apps/signer"
+	"github.com/tez-capital/tezbake/cli"
+	"github.com/tez-capital/tezbake/constants"
+	"github.com/tez-capital/tezbake/util"
+	"go.alis.is/common/log"
+
+	"github.com/jedib0t/go-pretty/v6/table"
+	"github.com/spf13/cobra"
+)
+
+type KeyRole string
+
+const (
+	KeyRoleBaker     KeyRole = "baker"
+	KeyRoleConsensus KeyRole = "consensus"
+	KeyRoleCompanion KeyRole = "companion"
+)
+
+type keyInventoryEntry struct {
+	Alias      string  `json:"alias"`
+	Pkh        string  `json:"pkh"`
+	Kind       string  `json:"kind"`
+	LedgerPath string  `json:"ledger_path,omitempty"`
+	AppVersion string  `json:"app_version,omitempty"`
+	Authorized bool    `json:"authorized"`
+	Status     string  `json:"status"`
+	Role       KeyRole `json:"role,omitempty"`
+	// NodeMatch tells whether consensus/companion key is among node's additional baking keys,
+	// nil if not applicable or node info is not available
+	NodeMatch *bool `json:"node_match,omitempty"`
+}
+
+type keyInventory struct {
+	Keys []keyInventoryEntry `json:"keys"`
+	// NodeKeysMissingInSigner are node's additional baking keys the signer does not manage
+	NodeKeysMissingInSigner map[string]string `json:"node_keys_missing_in_signer,omitempty"`
+}
+
+func getWalletStatus(wallet base.AmiWalletInfo) string {
+	switch wallet.Kind {
+	case "ledger":
+		if wallet.LedgerStatus == "connected" && wallet.Authorized {
+			return "ok"
+		}
+		return "error"
+	case "tezsign":
+		if wallet.Locked {
+			return "locked"
+		}
+		if wallet.Authorized {
+			return "ok"
+		}
+		return "error"
+	case "http", "remote":
+		if wallet.Status == "" {
+			return "unknown"
+		}
+		return wallet.Status
+	case "soft":
+		return "ok (unencrypted)"
+	default:
+		return "n/a"
+	}
+}
+
+// getKeyRole determines role of the key from delegate keys resolved through node rpc,
+// if node rpc is not available (delegateKeys is nil) the role is guessed from node's additional baking keys and alias naming
+func getKeyRole(alias string, pkh string, nodeKeys map[string]string, delegateKeys map[string]util.DelegateKey) KeyRole {
+	if delegateKeys != nil {
+		switch delegateKeys[pkh].Kind {
+		case util.DelegateKeyKindDelegate:
+			return KeyRoleBaker
+		case util.DelegateKeyKindConsensus:
+			return KeyRoleConsensus
+		case util.DelegateKeyKindCompanion:
+			return KeyRoleCompanion
+		default:
+			return ""
+		}
+	}
+
+	if alias == "baker" {
+		return KeyRoleBaker
+	}
+	names := []string{alias}
+	for name, address := range nodeKeys {
+		if address == pkh {
+			names = append(names, name)
+		}
+	}
+	for _, name := range names {
+		switch {
+		case strings.Contains(strings.ToLower(name), string(KeyRoleCompanion)):
+			return KeyRoleCompanion
+		case strings.Contains(strings.ToLower(name), string(KeyRoleConsensus)):
+			return KeyRoleConsensus
+		}
+	}
+	if len(names) > 1 {
+		// referenced by node but not named, additional keys are consensus keys unless stated otherwise
+		return KeyRoleConsensus
+	}
+	return ""
+}
+
+// buildKeyInventory merges signer wallets with node's additional baking keys,
+// nodeKeys is nil if node info is not available, delegateKeys is nil if node rpc is not available
+func buildKeyInventory(wallets map[string]base.AmiWalletInfo, nodeKeys map[string]string, delegateKeys map[string]util.DelegateKey) keyInventory {
+	aliases := lo.Keys(wallets)
+	sort.Strings(aliases)
+
+	result := keyInventory{Keys: make([]keyInventoryEntry, 0, len(aliases))}
+	for _, alias := range aliases {
+		wallet := wallets[alias]
+		entry := keyInventoryEntry{
+			Alias:      alias,
+			Pkh:        wallet.Pkh,
+			Kind:       wallet.Kind,
+			LedgerPath: lo.CoalesceOrEmpty(wallet.Ledger, wallet.DevicePath),
+			AppVersion: wallet.AppVersion,
+			Authorized: wallet.Authorized,
+			Status:     getWalletStatus(wallet),
+			Role:       getKeyRole(alias, wallet.Pkh, nodeKeys, delegateKeys),
+		}
+		if nodeKeys != nil && (entry.Role == KeyRoleConsensus || entry.Role == KeyRoleCompanion) {
+			entry.NodeMatch = lo.ToPtr(slices.Contains(lo.Values(nodeKeys), wallet.Pkh))
+		}
+		result.Keys = append(result.Keys, entry)
+	}
+
+	signerPkhs := lo.Map(result.Keys, func(entry keyInventoryEntry, _ int) string { return entry.Pkh })
+	missing := lo.PickBy(nodeKeys, func(_ string, address string) bool { return !slices.Contains(signerPkhs, address) })
+	if len(missing) > 0 {
+		result.NodeKeysMissingInSigner = missing
+	}
+	return result
+}
+
+// getDelegateKeys resolves keys to delegates through rpc of the node, returns nil if node rpc is not available
+func getDelegateKeys(pkhs []string) map[string]util.DelegateKey {
+	if !apps.Node.IsInstalled() {
+		return nil
+	}
+	rpc, err := apps.Node.NewRpcClient(30 * time.Second)
+	if err != nil {
+		log.Warn("Failed to connect to node rpc, guessing key roles from aliases.", "error", err.Error())
+		return nil
+	}
+	defer rpc.Close()
+	delegateKeys, err := rpc.NewAttestationProfileResolver().ResolveDelegateKeys(lo.Uniq(lo.Compact(pkhs)))
+	if err != nil {
+		log.Warn("Failed to resolve keys through node rpc, guessing key roles from aliases.", "error", err.Error())
+		return nil
+	}
+	return delegateKeys
+}
+
+func formatNodeMatch(nodeMatch *bool) string {
+	switch {
+	case nodeMatch == nil:
+		return "-"
+	case *nodeMatch:
+		return "yes"
+	default:
+		return "NO"
+	}
+}
+
+var keysCmd = &cobra.Command{
+	Use:   "keys",
+	Short: "Manages signer keys.",
+	Long:  "Inspects keys managed by the signer.",
+}
+
+var keysListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "Lists signer keys.",
+	Long: `Lists keys managed by the signer with their kind, ledger details, authorization status and role.
+
+Roles are determined from delegates and their active and pending consensus and companion keys through rpc of the node,
+if the node rpc is not available roles are guessed from aliases. Consensus and companion keys are checked against
+additional baking keys of the node.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		util.AssertBE(apps.Signer.IsInstalled(), "Signer is not installed!", constants.ExitAppNotInstalled)
+		signerInfo, err := apps.Signer.GetInfoFromOptions(&signer.InfoCollectionOptions{Wallets: true})
+		util.AssertEE(err, "Failed to collect signer keys!", constants.ExitExternalError)
+
+		var nodeKeys map[string]string
+		if apps.Node.IsInstalled() {
+			nodeInfo, err := apps.Node.GetInfoFromOptions(&node.InfoCollectionOptions{Keys: true})
+			if err != nil {
+				log.Warn("Failed to collect node baking keys, skipping node check.", "error", err.Error())
+			} else {
+				nodeKeys = lo.Assign(map[string]string{}, nodeInfo.AdditionalBakingKeys)
+			}
+		}
+
+		pkhs := lo.MapToSlice(signerInfo.Wallets, func(_ string, wallet base.AmiWalletInfo) string { return wallet.Pkh })
+		inventory := buildKeyInventory(signerInfo.Wallets, nodeKeys, getDelegateKeys(pkhs))
+
+		if cli.JsonLogFormat {
+			output, err := json.Marshal(inventory)
+			util.AssertEE(err, "Failed to serialize signer keys!", constants.ExitSerializationFailed)
+			fmt.Println(string(output))
+			return
+		}
+
+		keysTable := table.NewWriter()
+		keysTable.SetStyle(table.StyleLight)
+		keysTable.SetOutputMirror(os.Stdout)
+		keysTable.AppendHeader(table.Row{"Alias", "Address", "Kind", "Role", "Ledger", "App Version", "Authorized", "Status", "In Node"})
+		for _, entry := range inventory.Keys {
+			keysTable.AppendRow(table.Row{entry.Alias, entry.Pkh, entry.Kind, entry.Role, entry.LedgerPath, entry.AppVersion, entry.Authorized, entry.Status, formatNodeMatch(entry.NodeMatch)})
+		}
+		if len(inventory.Keys) == 0 {
+			keysTable.AppendRow(table.Row{"N/A"})
+		}
+		keysTable.Render()
+
+		if len(inventory.NodeKeysMissingInSigner) > 0 {
+			names := lo.Keys(inventory.NodeKeysMissingInSigner)
+			sort.Strings(names)
+			for _, name := range names {
+				log.Warn("Node baking key is not managed by the signer!", "name", name, "address", inventory.NodeKeysMissingInSigner[name])
+			}
+		}
+	},
+}
+
+func init() {
+	keysCmd.AddCommand(keysListCmd)
+	RootCmd.AddCommand(keysCmd)
+}
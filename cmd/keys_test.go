@@ -0,0 +1,93 @@
+package cmd
+
+import (
+	"testing"
+
+	"github.com/tez-capital/tezbake/apps/base"
+	"github.com/tez-capital/tezbake/util"
+)
+
+func TestBuildKeyInventory(t *testing.T) {
+	wallets := map[string]base.AmiWalletInfo{
+		"baker":     {Kind: "ledger", Pkh: "tz1baker", Ledger: "ledger://a/b", AppVersion: "2.4.0", LedgerStatus: "connected", Authorized: true},
+		"consensus": {Kind: "tezsign", Pkh: "tz4consensus", Authorized: true},
+		"companion": {Kind: "tezsign", Pkh: "tz4companion"},
+		"other":     {Kind: "soft", Pkh: "tz1other"},
+		"renamed":   {Kind: "remote", Pkh: "tz4renamed"},
+		"offsite":   {Kind: "remote", Pkh: "tz1offsite", Status: "ok"},
+	}
+	nodeKeys := map[string]string{
+		"consensus_key": "tz4consensus",
+		"extra":         "tz4renamed",
+		"missing":       "tz4missing",
+	}
+
+	inventory := buildKeyInventory(wallets, nodeKeys, nil)
+	entries := map[string]keyInventoryEntry{}
+	for _, entry := range inventory.Keys {
+		entries[entry.Alias] = entry
+	}
+
+	expectations := []struct {
+		alias     string
+		role      KeyRole
+		status    string
+		nodeMatch string
+	}{
+		{alias: "baker", role: KeyRoleBaker, status: "ok", nodeMatch: "-"},
+		{alias: "consensus", role: KeyRoleConsensus, status: "ok", nodeMatch: "yes"},
+		{alias: "companion", role: KeyRoleCompanion, status: "error", nodeMatch: "NO"},
+		{alias: "other", role: "", status: "ok (unencrypted)", nodeMatch: "-"},
+		{alias: "renamed", role: KeyRoleConsensus, status: "unknown", nodeMatch: "yes"},
+		{alias: "offsite", role: "", status: "ok", nodeMatch: "-"},
+	}
+	for _, expected := range expectations {
+		entry := entries[expected.alias]
+		if entry.Role != expected.role || entry.Status != expected.status || formatNodeMatch(entry.NodeMatch) != expected.nodeMatch {
+			t.Errorf("unexpected entry for %s: role=%s status=%s node_match=%s", expected.alias, entry.Role, entry.Status, formatNodeMatch(entry.NodeMatch))
+		}
+	}
+	if entries["baker"].LedgerPath != "ledger://a/b" {
+		t.Errorf("unexpected ledger path: %s", entries["baker"].LedgerPath)
+	}
+	if len(inventory.NodeKeysMissingInSigner) != 1 || inventory.NodeKeysMissingInSigner["missing"] != "tz4missing" {
+		t.Errorf("unexpected missing node keys: %v", inventory.NodeKeysMissingInSigner)
+	}
+
+	withoutNode := buildKeyInventory(wallets, nil, nil)
+	for _, entry := range withoutNode.Keys {
+		if entry.NodeMatch != nil {
+			t.Errorf("expected no node match without node info for %s", entry.Alias)
+		}
+	}
+}
+
+func TestBuildKeyInventoryWithDelegateKeys(t *testing.T) {
+	wallets := map[string]base.AmiWalletInfo{
+		"baker":     {Kind: "ledger", Pkh: "tz1baker"},
+		"hot":       {Kind: "tezsign", Pkh: "tz4hot"},
+		"consensus": {Kind: "tezsign", Pkh: "tz4companion"},
+		"payout":    {Kind: "soft", Pkh: "tz1payout"},
+		"cold":      {Kind: "ledger", Pkh: "tz1cold"},
+	}
+	delegateKeys := map[string]util.DelegateKey{
+		"tz1baker":     {Delegate: "tz1baker", Kind: util.DelegateKeyKindDelegate},
+		"tz4hot":       {Delegate: "tz1baker", Kind: util.DelegateKeyKindConsensus},
+		"tz4companion": {Delegate: "tz1baker", Kind: util.DelegateKeyKindCompanion},
+		"tz1cold":      {Delegate: "tz1cold", Kind: util.DelegateKeyKindDelegate},
+	}
+
+	inventory := buildKeyInventory(wallets, map[string]string{"consensus_key": "tz4hot"}, delegateKeys)
+	expected := map[string]KeyRole{
+		"baker":     KeyRoleBaker,
+		"hot":       KeyRoleConsensus,
+		"consensus": KeyRoleCompanion,
+		"payout":    "",
+		"cold":      KeyRoleBaker,
+	}
+	for _, entry := range inventory.Keys {
+		if entry.Role != expected[entry.Alias] {
+			t.Errorf("expected role %s for %s, got %s", expected[entry.Alias], entry.Alias, entry.Role)
+		}
+	}
+}
@@ -0,0 +1,285 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"regexp"
+	"strconv"
+
+	"github.com/samber/lo"
+	"github.com/tez-capital/tezbake/apps"
+	"github.com/tez-capital/tezbake/apps/signer"
+	"github.com/tez-capital/tezbake/cli"
+	"github.com/tez-capital/tezbake/constants"
+	"github.com/tez-capital/tezbake/system"
+	"github.com/tez-capital/tezbake/util"
+	"go.alis.is/common/log"
+
+	"github.com/jedib0t/go-pretty/v6/table"
+	"github.com/spf13/cobra"
+)
+
+var (
+	// e.g. "- 1234 (round: 0) for block," of baking app 2.x
+	ledgerHwmValuesRegex = regexp.MustCompile(`(\d+) \(round: (\d+)\) for (\w+)`)
+	// e.g. "The high water mark for ledger://... is 1234." of older baking apps
+	ledgerHwmLegacyRegex = regexp.MustCompile(`is (\d+)(?: \(round: (\d+)\))?`)
+)
+
+type ledgerHwmValue struct {
+	Kind  string `json:"kind"`
+	Level int64  `json:"level"`
+	Round int64  `json:"round"`
+}
+
+type ledgerHwm struct {
+	Alias     string           `json:"alias"`
+	Ledger    string           `json:"ledger"`
+	ChainId   string           `json:"chain_id,omitempty"`
+	HeadLevel int64            `json:"head_level,omitempty"`
+	Values    []ledgerHwmValue `json:"values"`
+}
+
+type nodeHeadHeader struct {
+	ChainId string `json:"chain_id"`
+	Level   int64  `json:"level"`
+}
+
+// parseLedgerHwm parses output of 'get ledger high watermark'
+func parseLedgerHwm(output string) ([]ledgerHwmValue, error) {
+	result := make([]ledgerHwmValue, 0)
+	for _, match := range ledgerHwmValuesRegex.FindAllStringSubmatch(output, -1) {
+		level, _ := strconv.ParseInt(match[1], 10, 64)
+		round, _ := strconv.ParseInt(match[2], 10, 64)
+		result = append(result, ledgerHwmValue{Kind: match[3], Level: level, Round: round})
+	}
+	if len(result) > 0 {
+		return result, nil
+	}
+	if match := ledgerHwmLegacyRegex.FindStringSubmatch(output); match != nil {
+		level, _ := strconv.ParseInt(match[1], 10, 64)
+		round, _ := strconv.ParseInt(lo.CoalesceOrEmpty(match[2], "0"), 10, 64)
+		return append(result, ledgerHwmValue{Kind: "all", Level: level, Round: round}), nil
+	}
+	return nil, fmt.Errorf("unexpected high watermark output - %s", output)
+}
+
+// checkLedgerHwm checks the new high watermark against the current one and node head,
+// returns risks which should prevent setting it without --force
+func checkLedgerHwm(current []ledgerHwmValue, headLevel int64, newLevel int64) (risks []string, warnings []string) {
+	if currentLevel := lo.Max(lo.Map(current, func(value ledgerHwmValue, _ int) int64 { return value.Level })); newLevel < currentLevel {
+		risks = append(risks, fmt.Sprintf("new high watermark %d is below the current one %d, the device could sign levels it already signed (double signing)", newLevel, currentLevel))
+	}
+	if headLevel <= 0 {
+		warnings = append(warnings, "node head is not known, high watermark can not be checked against the chain")
+		return
+	}
+	if newLevel > headLevel+1 {
+		risks = append(risks, fmt.Sprintf("new high watermark %d is ahead of node head %d, the device will refuse to sign %d levels (missed rights)", newLevel, headLevel, newLevel-headLevel-1))
+	}
+	if newLevel < headLevel {
+		warnings = append(warnings, fmt.Sprintf("new high watermark %d is below node head %d, if the key was used on another device it may have signed these levels", newLevel, headLevel))
+	}
+	return
+}
+
+// getLedgerUri returns ledger uri of the signer key
+func getLedgerUri(alias string) (string, error) {
+	signerInfo, err := apps.Signer.GetInfoFromOptions(&signer.InfoCollectionOptions{Wallets: true})
+	if err != nil {
+		return "", fmt.Errorf("failed to collect signer keys - %s", err.Error())
+	}
+	wallet, ok := signerInfo.Wallets[alias]
+	if !ok {
+		return "", fmt.Errorf("key '%s' not found in signer", alias)
+	}
+	if wallet.Kind != "ledger" || wallet.Ledger == "" {
+		return "", fmt.Errorf("key '%s' is not a ledger key", alias)
+	}
+	return wallet.Ledger, nil
+}
+
+// withSignerStoppedForLedger stops signer so client can access the ledger, runs fn and starts signer again if it was running.
+// Nothing in fn may exit the process, the signer would stay stopped and the baker would miss duties.
+func withSignerStoppedForLedger(fn func() error) error {
+	wasRunning, _ := apps.Signer.IsServiceStatus(constants.SignerAppServiceId, "running")
+	if wasRunning {
+		log.Info("Stopping signer to access the ledger...")
+		if exitCode, err := apps.Signer.Stop(); err != nil {
+			return fmt.Errorf("failed to stop signer (exit code %d) - %s", exitCode, err.Error())
+		}
+	}
+	err := fn()
+	if wasRunning {
+		log.Info("Starting signer...")
+		if exitCode, startErr := apps.Signer.Start(); startErr != nil {
+			log.Error("Failed to start signer, please start it manually!", "exit_code", exitCode, "error", startErr.Error())
+			if err == nil {
+				err = fmt.Errorf("failed to start signer - %s", startErr.Error())
+			}
+		}
+	}
+	return err
+}
+
+// getLedgerHwmValues reads high watermark of the ledger, signer has to be stopped
+func getLedgerHwmValues(ledger string) ([]ledgerHwmValue, error) {
+	output, exitCode, err := apps.Signer.ExecuteGetOutput("client", "get", "ledger", "high", "watermark", "for", ledger)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get ledger high watermark - %s", err.Error())
+	}
+	if exitCode != 0 {
+		return nil, fmt.Errorf("failed to get ledger high watermark (exit code %d)", exitCode)
+	}
+	return parseLedgerHwm(output)
+}
+
+func collectLedgerHwm(alias string) (ledgerHwm, error) {
+	ledger, err := getLedgerUri(alias)
+	if err != nil {
+		return ledgerHwm{}, err
+	}
+	result := ledgerHwm{Alias: alias, Ledger: ledger}
+
+	var head nodeHeadHeader
+	if err := apps.Signer.RpcGet("/chains/main/blocks/head/header", &head); err != nil {
+		log.Warn("Failed to get node head!", "error", err.Error())
+	}
+	result.ChainId, result.HeadLevel = head.ChainId, head.Level
+
+	err = withSignerStoppedForLedger(func() error {
+		var err error
+		result.Values, err = getLedgerHwmValues(result.Ledger)
+		return err
+	})
+	return result, err
+}
+
+// setLedgerHwm sets high watermark of the ledger, the high watermark is re-checked with the signer stopped
+// as the signer could have signed higher levels while waiting for confirmation
+func setLedgerHwm(ledger string, newLevel int64, force bool) error {
+	return withSignerStoppedForLedger(func() error {
+		current, err := getLedgerHwmValues(ledger)
+		if err != nil {
+			return err
+		}
+		if risks, _ := checkLedgerHwm(current, 0, newLevel); len(risks) > 0 && !force {
+			return fmt.Errorf("high watermark changed, refusing to set it - %s", risks[0])
+		}
+		exitCode, err := apps.Signer.Execute("client", "set", "ledger", "high", "watermark", "for", ledger, "to", strconv.FormatInt(newLevel, 10))
+		if err != nil {
+			return fmt.Errorf("failed to set ledger high watermark - %s", err.Error())
+		}
+		if exitCode != 0 {
+			return fmt.Errorf("failed to set ledger high watermark (exit code %d)", exitCode)
+		}
+		return nil
+	})
+}
+
+func printLedgerHwm(hwm ledgerHwm) {
+	if cli.JsonLogFormat {
+		output, err := json.Marshal(hwm)
+		util.AssertEE(err, "Failed to serialize ledger high watermark!", constants.ExitSerializationFailed)
+		fmt.Println(string(output))
+		return
+	}
+
+	hwmTable := table.NewWriter()
+	hwmTable.SetStyle(table.StyleLight)
+	hwmTable.SetOutputMirror(os.Stdout)
+	hwmTable.AppendHeader(table.Row{"Kind", "Level", "Round", "Head Distance"})
+	for _, value := range hwm.Values {
+		distance := "-"
+		if hwm.HeadLevel > 0 {
+			distance = fmt.Sprintf("%+d", value.Level-hwm.HeadLevel)
+		}
+		hwmTable.AppendRow(table.Row{value.Kind, value.Level, value.Round, distance})
+	}
+	hwmTable.AppendFooter(table.Row{"Ledger", hwm.Ledger, "Chain", fmt.Sprintf("%s (head %d)", lo.CoalesceOrEmpty(hwm.ChainId, "-"), hwm.HeadLevel)})
+	hwmTable.Render()
+}
+
+var ledgerCmd = &cobra.Command{
+	Use:   "ledger",
+	Short: "Manages ledger devices.",
+	Long:  "Manages ledger devices used by the signer.",
+}
+
+var ledgerHwmCmd = &cobra.Command{
+	Use:   "hwm",
+	Short: "Manages high watermark of ledger baking app.",
+	Long: `Manages high watermark of ledger baking app.
+
+The signer is stopped only while the ledger is accessed and started again afterwards,
+it keeps running during confirmation.`,
+}
+
+var ledgerHwmShowCmd = &cobra.Command{
+	Use:   "show [--key-alias <alias>]",
+	Short: "Prints high watermark of ledger baking app.",
+	Long:  "Prints level and round of the ledger baking app high watermark and compares it to the node head.",
+	Args:  cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		util.AssertBE(apps.Signer.IsInstalled(), "Signer is not installed!", constants.ExitAppNotInstalled)
+		system.RequireElevatedUser()
+
+		hwm, err := collectLedgerHwm(util.GetCommandStringFlagSD(cmd, "key-alias", "baker"))
+		util.AssertEE(err, "Failed to collect ledger high watermark!", constants.ExitExternalError)
+		printLedgerHwm(hwm)
+	},
+}
+
+var ledgerHwmSetCmd = &cobra.Command{
+	Use:   "set [<level>] [--key-alias <alias>] [--force]",
+	Short: "Sets high watermark of ledger baking app.",
+	Long: `Sets high watermark of ledger baking app, defaults to the node head level.
+
+Setting is refused if the level is below the current high watermark (double signing risk)
+or ahead of the node head (the ledger would refuse to sign and rights would be missed) unless --force is used.`,
+	Args: cobra.MaximumNArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		util.AssertBE(apps.Signer.IsInstalled(), "Signer is not installed!", constants.ExitAppNotInstalled)
+		system.RequireElevatedUser()
+		force := util.GetCommandBoolFlagS(cmd, "force")
+
+		hwm, err := collectLedgerHwm(util.GetCommandStringFlagSD(cmd, "key-alias", "baker"))
+		util.AssertEE(err, "Failed to collect ledger high watermark!", constants.ExitExternalError)
+
+		newLevel := hwm.HeadLevel
+		if len(args) > 0 {
+			newLevel, err = strconv.ParseInt(args[0], 10, 64)
+			util.AssertBE(err == nil && newLevel >= 0, "Invalid level!", constants.ExitInvalidArgs)
+		}
+		util.AssertBE(newLevel > 0, "Node head is not available, please specify the level!", constants.ExitInvalidArgs)
+
+		risks, warnings := checkLedgerHwm(hwm.Values, hwm.HeadLevel, newLevel)
+		for _, warning := range warnings {
+			log.Warn(warning)
+		}
+		for _, risk := range risks {
+			log.Error(risk)
+		}
+		util.AssertBE(len(risks) == 0 || force, "Refusing to set high watermark, use --force to override!", constants.ExitInvalidArgs)
+
+		if !util.GetCommandBoolFlagS(cmd, "confirm") {
+			util.ConfirmOrExit(fmt.Sprintf("Set high watermark of %s to %d?", hwm.Ledger, newLevel), len(risks) == 0, "Failed to confirm high watermark update!")
+		}
+		err = setLedgerHwm(hwm.Ledger, newLevel, force)
+		util.AssertEE(err, "Failed to set ledger high watermark!", constants.ExitExternalError)
+		log.Info("Ledger high watermark set.", "ledger", hwm.Ledger, "level", newLevel)
+	},
+}
+
+func init() {
+	for _, command := range []*cobra.Command{ledgerHwmShowCmd, ledgerHwmSetCmd} {
+		command.Flags().String("key-alias", "baker", "Alias of the ledger key.")
+	}
+	ledgerHwmSetCmd.Flags().Bool("force", false, "Sets high watermark despite double signing or missed rights risk.")
+	ledgerHwmSetCmd.Flags().Bool("confirm", false, "Skips confirmation.")
+
+	ledgerHwmCmd.AddCommand(ledgerHwmShowCmd, ledgerHwmSetCmd)
+	ledgerCmd.AddCommand(ledgerHwmCmd)
+	RootCmd.AddCommand(ledgerCmd)
+}
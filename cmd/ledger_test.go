@@ -0,0 +1,47 @@
+package cmd
+
+import (
+	"testing"
+)
+
+func TestParseLedgerHwm(t *testing.T) {
+	values, err := parseLedgerHwm(`The high water mark values for ledger://a-b-c-d/bip25519/0h/0h are
+- 120 (round: 1) for block,
+- 121 (round: 0) for preattestation,
+- 121 (round: 0) for attestation.`)
+	if err != nil || len(values) != 3 {
+		t.Fatalf("unexpected result: %v %v", values, err)
+	}
+	if values[0] != (ledgerHwmValue{Kind: "block", Level: 120, Round: 1}) || values[2].Kind != "attestation" || values[2].Level != 121 {
+		t.Errorf("unexpected values: %v", values)
+	}
+
+	values, err = parseLedgerHwm("The high water mark for ledger://a-b-c-d/ed25519/0h/0h is 42.")
+	if err != nil || len(values) != 1 || values[0].Level != 42 || values[0].Kind != "all" {
+		t.Errorf("unexpected legacy result: %v %v", values, err)
+	}
+
+	if _, err := parseLedgerHwm("Error: no ledger"); err == nil {
+		t.Error("expected error for unexpected output")
+	}
+}
+
+func TestCheckLedgerHwm(t *testing.T) {
+	current := []ledgerHwmValue{{Kind: "block", Level: 100}, {Kind: "attestation", Level: 101}}
+
+	if risks, warnings := checkLedgerHwm(current, 105, 105); len(risks) != 0 || len(warnings) != 0 {
+		t.Errorf("expected head level to be safe: %v %v", risks, warnings)
+	}
+	if risks, _ := checkLedgerHwm(current, 105, 90); len(risks) != 1 {
+		t.Errorf("expected double signing risk: %v", risks)
+	}
+	if risks, _ := checkLedgerHwm(current, 105, 200); len(risks) != 1 {
+		t.Errorf("expected missed rights risk: %v", risks)
+	}
+	if risks, warnings := checkLedgerHwm(current, 105, 102); len(risks) != 0 || len(warnings) != 1 {
+		t.Errorf("expected warning below head: %v %v", risks, warnings)
+	}
+	if risks, warnings := checkLedgerHwm(current, 0, 102); len(risks) != 0 || len(warnings) != 1 {
+		t.Errorf("expected warning without head: %v %v", risks, warnings)
+	}
+}
@@ -0,0 +1,109 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/samber/lo"
+	"github.com/tez-capital/tezbake/apps"
+	"github.com/tez-capital/tezbake/cli"
+	"github.com/tez-capital/tezbake/constants"
+	"github.com/tez-capital/tezbake/system"
+	"github.com/tez-capital/tezbake/util"
+	"go.alis.is/common/log"
+
+	"github.com/jedib0t/go-pretty/v6/table"
+	"github.com/spf13/cobra"
+)
+
+func describeLinkUpdate(check apps.LinkCheck) string {
+	if check.Status == apps.LinkStatusDangling {
+		return fmt.Sprintf("%s - %s '%s' is set but %s is not installed. Do you want to remove it?", check.Target, check.Key, check.Current, check.Source)
+	}
+	return fmt.Sprintf("%s - %s '%s' is different from actual %s endpoint '%s'. Do you want to update it?", check.Target, check.Key, check.Current, check.Source, check.Expected)
+}
+
+// reconcileLinks applies missing links, mismatched and dangling links are applied only if forced or confirmed
+func reconcileLinks(force bool) {
+	toApply := make([]apps.LinkCheck, 0)
+	for _, check := range apps.CheckLinks() {
+		switch check.Status {
+		case apps.LinkStatusMissing:
+			toApply = append(toApply, check)
+		case apps.LinkStatusMismatch, apps.LinkStatusDangling:
+			if force || (system.IsTty() && util.Confirm(describeLinkUpdate(check), false, "Failed to confirm link update!")) {
+				toApply = append(toApply, check)
+			}
+		case apps.LinkStatusUnresolved:
+			log.Warn("Failed to resolve link!", "target", check.Target, "key", check.Key, "source", check.Source, "error", check.Message)
+		}
+		if check.Expected != "" && check.Message != "" {
+			log.Warn(check.Message, "target", check.Target, "key", check.Key)
+		}
+	}
+	for _, check := range toApply {
+		log.Info("Updating link", "target", check.Target, "key", check.Key, "endpoint", check.Expected)
+	}
+	util.AssertEE(apps.ApplyLinks(toApply), "Failed to apply links!", constants.ExitInternalError)
+}
+
+var linkCmd = &cobra.Command{
+	Use:   "link",
+	Short: "Manages endpoints between BB apps.",
+	Long: `Validates and fixes endpoints apps use to reach each other - node's dal endpoint, dal's node endpoint,
+signer's node endpoint, peak's node and signer endpoints and pay's node endpoint.
+
+Endpoints of remote apps are translated to addresses reachable from the linked app.`,
+}
+
+var linkCheckCmd = &cobra.Command{
+	Use:   "check",
+	Short: "Checks endpoints between BB apps.",
+	Long:  "Checks endpoints between BB apps and exits with non-zero exit code if any of them needs to be fixed.",
+	Args:  cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		checks := apps.CheckLinks()
+
+		if cli.JsonLogFormat {
+			output, err := json.Marshal(checks)
+			util.AssertEE(err, "Failed to serialize link checks!", constants.ExitSerializationFailed)
+			fmt.Println(string(output))
+		} else {
+			linksTable := table.NewWriter()
+			linksTable.SetStyle(table.StyleLight)
+			linksTable.SetOutputMirror(os.Stdout)
+			linksTable.AppendHeader(table.Row{"Target", "Key", "Source", "Current", "Expected", "Status", "Message"})
+			for _, check := range checks {
+				linksTable.AppendRow(table.Row{check.Target, check.Key, check.Source, check.Current, check.Expected, check.Status, check.Message})
+			}
+			linksTable.Render()
+		}
+
+		if lo.SomeBy(checks, func(check apps.LinkCheck) bool {
+			return check.NeedsUpdate() || check.Status == apps.LinkStatusUnresolved
+		}) {
+			os.Exit(constants.ExitCheckFailed)
+		}
+	},
+}
+
+var linkApplyCmd = &cobra.Command{
+	Use:   "apply [--force]",
+	Short: "Fixes endpoints between BB apps.",
+	Long: `Sets missing endpoints between BB apps.
+
+Endpoints pointing elsewhere and endpoints of apps which are not installed anymore are updated only after confirmation or with --force.`,
+	Args: cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		system.RequireElevatedUser()
+		reconcileLinks(util.GetCommandBoolFlagS(cmd, "force"))
+		log.Info("Links applied.")
+	},
+}
+
+func init() {
+	linkApplyCmd.Flags().Bool("force", false, "Updates endpoints without confirmation.")
+	linkCmd.AddCommand(linkCheckCmd, linkApplyCmd)
+	RootCmd.AddCommand(linkCmd)
+}
@@ -0,0 +1,140 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"slices"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/charmbracelet/lipgloss"
+	"github.com/samber/lo"
+	"github.com/tez-capital/tezbake/ami"
+	"github.com/tez-capital/tezbake/apps"
+	"github.com/tez-capital/tezbake/apps/base"
+	"github.com/tez-capital/tezbake/cli"
+	"github.com/tez-capital/tezbake/constants"
+	"github.com/tez-capital/tezbake/system"
+	"github.com/tez-capital/tezbake/util"
+	"go.alis.is/common/log"
+
+	"github.com/spf13/cobra"
+)
+
+var logPrefixColors = []lipgloss.Color{"6", "3", "5", "2", "4"}
+
+type logLine struct {
+	App  string `json:"app"`
+	Line string `json:"line"`
+}
+
+// normalizeLogsSince converts durations (e.g. 1h) to absolute time, other values are passed as they are
+func normalizeLogsSince(since string) string {
+	if duration, err := time.ParseDuration(since); err == nil {
+		return time.Now().Add(-duration).UTC().Format("2006-01-02 15:04:05 UTC")
+	}
+	return since
+}
+
+func getLogPrefixes(selectedApps []base.BakeBuddyApp, noColor bool) map[string]string {
+	width := lo.Max(lo.Map(selectedApps, func(app base.BakeBuddyApp, _ int) int { return len(app.GetId()) }))
+	prefixes := make(map[string]string, len(selectedApps))
+	for _, app := range selectedApps {
+		prefix := fmt.Sprintf("%-*s |", width, app.GetId())
+		if !noColor {
+			color := logPrefixColors[slices.Index(apps.All, app)%len(logPrefixColors)]
+			prefix = lipgloss.NewStyle().Foreground(color).Bold(true).Render(prefix)
+		}
+		prefixes[app.GetId()] = prefix
+	}
+	return prefixes
+}
+
+var logsCmd = &cobra.Command{
+	Use:   "logs [--node|--signer|--dal|--peak|--pay] [--service <service>] [-f] [--since <time>]",
+	Short: "Prints logs of BB services.",
+	Long: `Prints logs of services of installed apps through the app log facility (journald).
+
+Logs of multiple apps are multiplexed and prefixed with the app id, with -o json every line is printed as json object.
+Remote apps are streamed over ssh transparently.`,
+	Args: cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		system.RequireElevatedUser()
+
+		selectedApps := GetAppsBySelectionCriteria(cmd, AppSelectionCriteria{
+			InitialSelection:  InstalledApps,
+			FallbackSelection: ImplicitApps,
+		})
+		util.AssertBE(len(selectedApps) > 0, "No installed apps selected!", constants.ExitAppNotInstalled)
+
+		follow, _ := cmd.Flags().GetBool("follow")
+		logOptions := ami.LogOptions{
+			Service: util.GetCommandStringFlagS(cmd, "service"),
+			Follow:  follow,
+			Since:   normalizeLogsSince(util.GetCommandStringFlagS(cmd, "since")),
+		}
+		noColor, _ := cmd.Flags().GetBool(NO_COLOR_FLAG)
+		prefixes := getLogPrefixes(selectedApps, noColor)
+
+		lines := make(chan logLine)
+		var printer sync.WaitGroup
+		printer.Add(1)
+		go func() {
+			defer printer.Done()
+			for line := range lines {
+				if cli.JsonLogFormat {
+					output, err := json.Marshal(line)
+					if err != nil {
+						continue
+					}
+					fmt.Println(string(output))
+					continue
+				}
+				fmt.Println(prefixes[line.App], line.Line)
+			}
+		}()
+
+		var wg sync.WaitGroup
+		var failed atomic.Bool
+		for _, app := range selectedApps {
+			wg.Add(1)
+			go func(app base.BakeBuddyApp) {
+				defer wg.Done()
+				output := make(chan string)
+				done := make(chan struct{})
+				go func() {
+					defer close(done)
+					for line := range output {
+						lines <- logLine{App: app.GetId(), Line: line}
+					}
+				}()
+				exitCode, err := ami.StreamAppLogs(app.GetPath(), output, logOptions)
+				close(output)
+				<-done
+				if err != nil || exitCode != 0 {
+					log.Error("Failed to get logs!", "app", app.GetId(), "exit_code", exitCode)
+					failed.Store(true)
+				}
+			}(app)
+		}
+		wg.Wait()
+		close(lines)
+		printer.Wait()
+
+		if failed.Load() {
+			os.Exit(constants.ExitExternalError)
+		}
+	},
+}
+
+func init() {
+	for _, v := range apps.All {
+		logsCmd.Flags().Bool(v.GetId(), false, fmt.Sprintf("Prints %s's logs.", v.GetId()))
+	}
+	logsCmd.Flags().String("service", "", "Prints logs of the service only.")
+	logsCmd.Flags().BoolP("follow", "f", false, "Follows logs.")
+	logsCmd.Flags().String("since", "", "Prints logs since the time, e.g. 1h or '2024-01-01 10:00'.")
+	RootCmd.AddCommand(logsCmd)
+}
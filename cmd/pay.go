@@ -1,17 +1,88 @@
 package cmd
 
 import (
+	"encoding/json"
+	"fmt"
 	"os"
+	"slices"
 	"strings"
 
 	"github.com/samber/lo"
 	"github.com/tez-capital/tezbake/apps"
+	"github.com/tez-capital/tezbake/apps/pay"
+	"github.com/tez-capital/tezbake/cli"
 	"github.com/tez-capital/tezbake/constants"
 	"github.com/tez-capital/tezbake/util"
+	"go.alis.is/common/log"
 
+	"github.com/jedib0t/go-pretty/v6/table"
 	"github.com/spf13/cobra"
 )
 
+type payoutsDryRunResult struct {
+	*pay.PayoutBlueprint
+	RequiredBalance   pay.Mutez             `json:"required_balance"`
+	PayoutWallet      *pay.PayoutWalletInfo `json:"payout_wallet,omitempty"`
+	SufficientBalance *bool                 `json:"sufficient_balance,omitempty"`
+}
+
+var payGeneratePayoutsCmd = &cobra.Command{
+	Use:   "generate-payouts [--dry-run] [tezpay args...]",
+	Short: "Generates payouts.",
+	Long: `Passes args through to tezpay generate-payouts.
+
+With --dry-run payouts are printed as table (json with tezbake -o json) together with a check
+whether the payout wallet balance covers them. Nothing is paid.`,
+	DisableFlagParsing: true,
+	Run: func(cmd *cobra.Command, args []string) {
+		util.AssertBE(apps.Pay.IsInstalled(), "Pay app is not installed!", constants.ExitAppNotInstalled)
+		if !slices.Contains(args, "--dry-run") {
+			exitCode, _ := apps.Pay.Execute(append([]string{"generate-payouts"}, args...)...)
+			os.Exit(exitCode)
+		}
+		args = lo.Without(args, "--dry-run")
+
+		blueprint, err := apps.Pay.GeneratePayouts(0, args...)
+		util.AssertEE(err, "Failed to generate payouts!", constants.ExitExternalError)
+
+		result := payoutsDryRunResult{PayoutBlueprint: blueprint, RequiredBalance: blueprint.RequiredBalance()}
+		// payout wallet only, balance is checked against the blueprint generated above
+		payInfo, err := apps.Pay.GetInfoFromOptions(&pay.InfoCollectionOptions{Payouts: true})
+		if err != nil {
+			log.Warn("Failed to collect payout wallet info!", "error", err.Error())
+		}
+		result.PayoutWallet = payInfo.PayoutWallet
+		result.SufficientBalance = pay.CheckPayoutWalletBalance(blueprint, result.PayoutWallet)
+
+		if cli.JsonLogFormat {
+			output, err := json.Marshal(result)
+			util.AssertEE(err, "Failed to serialize payouts!", constants.ExitSerializationFailed)
+			fmt.Println(string(output))
+			return
+		}
+
+		payoutsTable := table.NewWriter()
+		payoutsTable.SetStyle(table.StyleLight)
+		payoutsTable.SetOutputMirror(os.Stdout)
+		payoutsTable.AppendHeader(table.Row{"Cycle", "Delegator", "Recipient", "Kind", "Amount", "Fee", "Note"})
+		for _, payout := range blueprint.ValidPayouts() {
+			payoutsTable.AppendRow(table.Row{payout.Cycle, payout.Delegator, payout.Recipient, payout.Kind, payout.Amount, payout.Fee, payout.Note})
+		}
+		invalidPayouts := blueprint.InvalidPayouts()
+		if len(invalidPayouts) > 0 {
+			payoutsTable.AppendSeparator()
+			for _, payout := range invalidPayouts {
+				payoutsTable.AppendRow(table.Row{payout.Cycle, payout.Delegator, payout.Recipient, payout.Kind, "-", "-", lo.CoalesceOrEmpty(payout.Note, "invalid")})
+			}
+		}
+		payoutsTable.AppendFooter(table.Row{"", "", "", "Total", result.RequiredBalance, "", fmt.Sprintf("%d payouts, %d skipped", len(blueprint.ValidPayouts()), len(invalidPayouts))})
+		payoutsTable.Render()
+		if result.PayoutWallet != nil {
+			fmt.Printf("Payout wallet %s balance: %v\n", result.PayoutWallet.Address, result.PayoutWallet.Balance)
+		}
+	},
+}
+
 var payCmd = &cobra.Command{
 	Use:                "pay",
 	Short:              "Passes args through to tezpay app.",
@@ -36,6 +107,7 @@ var payCmd = &cobra.Command{
 
 func init() {
 	payCmd.Flags().SetInterspersed(false)
+	payCmd.AddCommand(payGeneratePayoutsCmd)
 
 	RootCmd.AddCommand(payCmd)
 }
@@ -0,0 +1,181 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/hjson/hjson-go/v4"
+	"github.com/samber/lo"
+	"github.com/tez-capital/tezbake/ami"
+	"github.com/tez-capital/tezbake/apps"
+	"github.com/tez-capital/tezbake/apps/base"
+	"github.com/tez-capital/tezbake/apps/signer"
+	"github.com/tez-capital/tezbake/cli"
+	"github.com/tez-capital/tezbake/constants"
+	"github.com/tez-capital/tezbake/system"
+	"github.com/tez-capital/tezbake/util"
+	"go.alis.is/common/log"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+)
+
+const (
+	PeakListenKey         = "LISTEN"
+	PeakBakersKey         = "BAKERS"
+	PeakNodeEndpointKey   = "NODE_ENDPOINT"
+	PeakSignerEndpointKey = "SIGNER_ENDPOINT"
+	PeakNotificationsKey  = "NOTIFICATIONS"
+)
+
+// getSignerBakers returns addresses of signer keys which are delegates, keys without a known role are skipped
+func getSignerBakers() ([]string, error) {
+	if !apps.Signer.IsInstalled() {
+		return nil, fmt.Errorf("signer is not installed")
+	}
+	signerInfo, err := apps.Signer.GetInfoFromOptions(&signer.InfoCollectionOptions{Wallets: true})
+	if err != nil {
+		return nil, err
+	}
+	pkhs := lo.MapToSlice(signerInfo.Wallets, func(_ string, wallet base.AmiWalletInfo) string { return wallet.Pkh })
+	inventory := buildKeyInventory(signerInfo.Wallets, nil, getDelegateKeys(pkhs))
+	return lo.FilterMap(inventory.Keys, func(entry keyInventoryEntry, _ int) (string, bool) {
+		return entry.Pkh, entry.Pkh != "" && entry.Role == KeyRoleBaker
+	}), nil
+}
+
+// getPeakLinkEndpoint resolves endpoint peak should use for the key from the link graph
+func getPeakLinkEndpoint(key string) string {
+	link, found := lo.Find(apps.Links, func(link apps.Link) bool { return link.Target == apps.Peak && link.TargetKey == key })
+	if !found || !link.Source.IsInstalled() {
+		return ""
+	}
+	endpoint, message, err := link.ResolveEndpoint()
+	if err != nil {
+		log.Warn("Failed to resolve endpoint!", "key", key, "error", err.Error())
+		return ""
+	}
+	if message != "" {
+		log.Warn(message, "key", key)
+	}
+	return endpoint
+}
+
+// parseNotifications parses notifications configuration from hjson or file with hjson
+func parseNotifications(value string) (map[string]any, error) {
+	data := []byte(value)
+	if !strings.HasPrefix(strings.TrimSpace(value), "{") {
+		var err error
+		if data, err = os.ReadFile(value); err != nil {
+			return nil, err
+		}
+	}
+	result := map[string]any{}
+	err := hjson.Unmarshal(data, &result)
+	return result, err
+}
+
+func promptPeakValue(message string, current string) string {
+	value, err := util.PromptText(message, current)
+	util.AssertEE(err, "Failed to read input!", constants.ExitInternalError)
+	return value
+}
+
+var peakConfigureCmd = &cobra.Command{
+	Use:   "configure [--listen <address>] [--baker <address>...] [--node-endpoint <url>] [--signer-endpoint <url>] [--notifications <hjson or file>] [--auto] [--interactive]",
+	Short: "Configures peak.",
+	Long: `Configures bakers peak watches, node and signer endpoints it checks, notifications and listen address.
+
+With --auto (default if nothing is specified) bakers are populated from the signer's keys which are delegates
+(resolved through node rpc, alias 'baker' if not available) and endpoints from the node and signer. With --interactive every value is prompted for,
+with the current or detected value as default.`,
+	Args: cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		util.AssertBE(apps.Peak.IsInstalled(), "Peak app is not installed!", constants.ExitAppNotInstalled)
+		system.RequireElevatedUser()
+
+		interactive := util.GetCommandBoolFlagS(cmd, "interactive")
+		// only peak flags count, inherited flags (e.g. --path, --output-format) do not configure peak
+		peakFlagsSet := false
+		cmd.LocalNonPersistentFlags().VisitAll(func(flag *pflag.Flag) { peakFlagsSet = peakFlagsSet || flag.Changed })
+		auto := util.GetCommandBoolFlagS(cmd, "auto") || interactive || !peakFlagsSet
+
+		configuration, err := apps.Peak.LoadAppConfiguration()
+		util.AssertEE(err, "Failed to load peak configuration!", constants.ExitAppConfigurationLoadFailed)
+
+		listen := util.GetCommandStringFlagS(cmd, "listen")
+		bakers, _ := cmd.Flags().GetStringSlice("baker")
+		nodeEndpoint := util.GetCommandStringFlagS(cmd, "node-endpoint")
+		signerEndpoint := util.GetCommandStringFlagS(cmd, "signer-endpoint")
+		notifications := util.GetCommandStringFlagS(cmd, "notifications")
+
+		if auto {
+			if len(bakers) == 0 {
+				detectedBakers, err := getSignerBakers()
+				if err != nil {
+					log.Warn("Failed to detect bakers from signer!", "error", err.Error())
+				}
+				bakers = detectedBakers
+			}
+			nodeEndpoint = lo.CoalesceOrEmpty(nodeEndpoint, getPeakLinkEndpoint(PeakNodeEndpointKey))
+			signerEndpoint = lo.CoalesceOrEmpty(signerEndpoint, getPeakLinkEndpoint(PeakSignerEndpointKey))
+		}
+
+		if interactive {
+			currentListen, _ := configuration[PeakListenKey].(string)
+			listen = promptPeakValue("Listen address:", lo.CoalesceOrEmpty(listen, currentListen))
+			bakers = lo.Compact(lo.Map(strings.Split(promptPeakValue("Bakers (comma separated):", strings.Join(bakers, ",")), ","), func(baker string, _ int) string {
+				return strings.TrimSpace(baker)
+			}))
+			nodeEndpoint = promptPeakValue("Node endpoint:", nodeEndpoint)
+			signerEndpoint = promptPeakValue("Signer endpoint:", signerEndpoint)
+			notifications = promptPeakValue("Notifications (hjson or path to file, empty to keep):", notifications)
+		}
+
+		if listen != "" {
+			configuration[PeakListenKey] = listen
+		}
+		if len(bakers) > 0 {
+			configuration[PeakBakersKey] = lo.Uniq(bakers)
+		}
+		if nodeEndpoint != "" {
+			configuration[PeakNodeEndpointKey] = nodeEndpoint
+		}
+		if signerEndpoint != "" {
+			configuration[PeakSignerEndpointKey] = signerEndpoint
+		}
+		if notifications != "" {
+			notificationsConfiguration, err := parseNotifications(notifications)
+			util.AssertEE(err, "Invalid notifications configuration!", constants.ExitInvalidArgs)
+			configuration[PeakNotificationsKey] = notificationsConfiguration
+		}
+
+		err = ami.UpdateAppConfiguration(apps.Peak.GetPath(), configuration)
+		util.AssertEE(err, "Failed to update peak configuration!", constants.ExitAppConfigurationLoadFailed)
+		exitCode, err := apps.Peak.Execute("setup", "--configure") // reconfigure to apply changes
+		util.AssertEE(err, "Failed to reconfigure peak!", exitCode)
+		util.AssertBE(exitCode == 0, "Failed to reconfigure peak!", exitCode)
+
+		if cli.JsonLogFormat {
+			output, err := json.Marshal(configuration)
+			util.AssertEE(err, "Failed to serialize peak configuration!", constants.ExitSerializationFailed)
+			fmt.Println(string(output))
+			return
+		}
+		log.Info("Peak configured.", "bakers", configuration[PeakBakersKey], "node_endpoint", configuration[PeakNodeEndpointKey], "signer_endpoint", configuration[PeakSignerEndpointKey], "listen", configuration[PeakListenKey])
+	},
+}
+
+func init() {
+	peakConfigureCmd.Flags().String("listen", "", "Address peak listens on.")
+	peakConfigureCmd.Flags().StringSlice("baker", []string{}, "Baker address to watch (can be repeated).")
+	peakConfigureCmd.Flags().String("node-endpoint", "", "Node rpc peak checks.")
+	peakConfigureCmd.Flags().String("signer-endpoint", "", "Signer endpoint peak checks.")
+	peakConfigureCmd.Flags().String("notifications", "", "Notifications configuration - hjson or path to hjson file.")
+	peakConfigureCmd.Flags().Bool("auto", false, "Detects bakers from signer and endpoints from node and signer.")
+	peakConfigureCmd.Flags().Bool("interactive", false, "Prompts for configuration values.")
+
+	peakCmd.AddCommand(peakConfigureCmd)
+}
@@ -0,0 +1,313 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/samber/lo"
+	"github.com/tez-capital/tezbake/apps"
+	"github.com/tez-capital/tezbake/apps/node"
+	"github.com/tez-capital/tezbake/cli"
+	"github.com/tez-capital/tezbake/constants"
+	"github.com/tez-capital/tezbake/util"
+	"go.alis.is/common/log"
+
+	"github.com/jedib0t/go-pretty/v6/table"
+	"github.com/spf13/cobra"
+)
+
+type missedBlockReport struct {
+	Delegate string           `json:"delegate"`
+	Level    int              `json:"level"`
+	Time     time.Time        `json:"time"`
+	Downtime *serviceDowntime `json:"downtime,omitempty"`
+}
+
+type missedAttestationReport struct {
+	Delegate string           `json:"delegate"`
+	Level    int              `json:"level"`
+	Time     time.Time        `json:"time"`
+	Slots    int              `json:"slots"`
+	Downtime *serviceDowntime `json:"downtime,omitempty"`
+}
+
+type performanceResult struct {
+	Cycle              int                       `json:"cycle"`
+	Levels             *node.CycleLevels         `json:"levels,omitempty"`
+	Bakers             []*node.BakerPerformance  `json:"bakers"`
+	MissedBlocks       []missedBlockReport       `json:"missed_blocks"`
+	MissedAttestations []missedAttestationReport `json:"missed_attestations"`
+	ServiceDowntimes   []serviceDowntime         `json:"service_downtimes"`
+	Errors             []string                  `json:"errors,omitempty"`
+}
+
+type tzktCycle struct {
+	Index      int       `json:"index"`
+	FirstLevel int       `json:"firstLevel"`
+	StartTime  time.Time `json:"startTime"`
+	LastLevel  int       `json:"lastLevel"`
+	EndTime    time.Time `json:"endTime"`
+}
+
+type tzktBakerRewards struct {
+	Blocks             int `json:"blocks"`
+	MissedBlocks       int `json:"missedBlocks"`
+	FutureBlocks       int `json:"futureBlocks"`
+	Attestations       int `json:"attestations"`
+	MissedAttestations int `json:"missedAttestations"`
+	FutureAttestations int `json:"futureAttestations"`
+}
+
+type tzktMissedAttestation struct {
+	Level     int       `json:"level"`
+	Timestamp time.Time `json:"timestamp"`
+	Slots     int       `json:"slots"`
+}
+
+func getTzktCycleLevels(tzktUrl string, cycle int) (*node.CycleLevels, error) {
+	var tzktCycle tzktCycle
+	if err := util.TzktGet(tzktUrl, fmt.Sprintf("v1/cycles/%d", cycle), &tzktCycle); err != nil {
+		return nil, err
+	}
+	return &node.CycleLevels{
+		Cycle:      cycle,
+		First:      tzktCycle.FirstLevel,
+		Last:       tzktCycle.LastLevel,
+		FirstTime:  tzktCycle.StartTime,
+		LastTime:   tzktCycle.EndTime,
+		IsComplete: tzktCycle.EndTime.Before(time.Now()),
+	}, nil
+}
+
+// getTzktBakerPerformance is used if the node does not keep context of the cycle, missed block levels are not provided
+func getTzktBakerPerformance(tzktUrl string, delegate string, cycle int) (*node.BakerPerformance, error) {
+	var rewards tzktBakerRewards
+	if err := util.TzktGet(tzktUrl, fmt.Sprintf("v1/rewards/bakers/%s/%d", delegate, cycle), &rewards); err != nil {
+		return nil, err
+	}
+	return &node.BakerPerformance{
+		Delegate:             delegate,
+		Cycle:                cycle,
+		Source:               "tzkt",
+		ExpectedBlocks:       rewards.Blocks + rewards.MissedBlocks,
+		BakedBlocks:          rewards.Blocks,
+		ExpectedAttestations: rewards.Attestations + rewards.MissedAttestations,
+		IncludedAttestations: rewards.Attestations,
+	}, nil
+}
+
+// getTzktMissedAttestations returns levels at which attestations of the delegate were missed in the cycle
+func getTzktMissedAttestations(tzktUrl string, delegate string, cycle int) ([]node.MissedAttestation, error) {
+	var rights []tzktMissedAttestation
+	path := fmt.Sprintf("v1/rights?baker=%s&cycle=%d&type=attestation&status=missed&select=level,timestamp,slots&limit=10000", delegate, cycle)
+	if err := util.TzktGet(tzktUrl, path, &rights); err != nil {
+		return nil, err
+	}
+	return lo.Map(rights, func(right tzktMissedAttestation, _ int) node.MissedAttestation {
+		return node.MissedAttestation{Level: right.Level, Time: right.Timestamp, Slots: right.Slots}
+	}), nil
+}
+
+// isBakingDowntime tells whether the downtime affected apps needed for baking
+func isBakingDowntime(downtime serviceDowntime) bool {
+	return lo.Contains(downtime.Apps, apps.Node.GetId()) || lo.Contains(downtime.Apps, apps.Signer.GetId()) || lo.Contains(downtime.Apps, apps.DalNode.GetId())
+}
+
+// correlateMissedBlocks pairs missed blocks with tezbake service downtimes covering them
+func correlateMissedBlocks(bakers []*node.BakerPerformance, downtimes []serviceDowntime) []missedBlockReport {
+	reports := make([]missedBlockReport, 0)
+	for _, baker := range bakers {
+		for _, missed := range baker.MissedBlocks {
+			reports = append(reports, missedBlockReport{Delegate: baker.Delegate, Level: missed.Level, Time: missed.Time, Downtime: findServiceDowntime(downtimes, missed.Time)})
+		}
+	}
+	return reports
+}
+
+// correlateMissedAttestations pairs missed attestations with tezbake service downtimes covering them
+func correlateMissedAttestations(bakers []*node.BakerPerformance, downtimes []serviceDowntime) []missedAttestationReport {
+	reports := make([]missedAttestationReport, 0)
+	for _, baker := range bakers {
+		for _, missed := range baker.MissedAttestations {
+			reports = append(reports, missedAttestationReport{Delegate: baker.Delegate, Level: missed.Level, Time: missed.Time, Slots: missed.Slots, Downtime: findServiceDowntime(downtimes, missed.Time)})
+		}
+	}
+	return reports
+}
+
+func formatPerformanceRate(done int, expected int) string {
+	if expected == 0 {
+		return "-"
+	}
+	return fmt.Sprintf("%d/%d (%.2f%%)", done, expected, float64(done)/float64(expected)*100)
+}
+
+func formatDowntime(downtime *serviceDowntime) string {
+	if downtime == nil {
+		return "-"
+	}
+	end := "not started by tezbake"
+	if downtime.End != nil {
+		end = downtime.End.Local().Format(time.DateTime)
+	}
+	return fmt.Sprintf("%s (%s) %s - %s", downtime.Action, strings.Join(downtime.Apps, ", "), downtime.Start.Local().Format(time.DateTime), end)
+}
+
+var performanceCmd = &cobra.Command{
+	Use:   "performance [--cycle <cycle>] [--key <pkh>...]",
+	Short: "Prints baking and attestation performance of baker keys in a cycle.",
+	Long: `Computes blocks baked vs expected (round 0 rights), attestations included vs expected (in slots)
+and DAL attestations of baker keys of the node in the cycle, by default the last completed one.
+
+Data is collected from rpc of the node. If the node does not keep context of the cycle, TzKT is used instead.
+Levels of missed attestations are not provided by node rpc and are collected from TzKT. Set --tzkt-url
+(or TEZBAKE_TZKT_URL) to the TzKT instance of your network, or use --no-tzkt to use the node only.
+Missed blocks and attestations are matched against stops, upgrades and bootstraps done through tezbake.`,
+	Args: cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		util.AssertBE(apps.Node.IsInstalled(), "Node is not installed!", constants.ExitAppNotInstalled)
+		timeout, _ := cmd.Flags().GetInt("timeout")
+		tzktUrl := getTzktUrl(cmd)
+
+		delegates, _ := cmd.Flags().GetStringSlice("key")
+		if len(delegates) == 0 {
+			var err error
+			delegates, err = getRightsDelegates()
+			util.AssertEE(err, "Failed to get baker keys!", constants.ExitExternalError)
+		}
+		util.AssertBE(len(delegates) > 0, "No baker keys found!", constants.ExitInvalidArgs)
+
+		rpc, err := apps.Node.NewRpcClient(time.Duration(timeout) * time.Second)
+		util.AssertEE(err, "Failed to connect to node rpc!", constants.ExitExternalError)
+		defer rpc.Close()
+		// blocks and participation are recorded for delegates, not for their consensus keys
+		delegates = resolveRightsDelegates(rpc, delegates)
+
+		cycle, _ := cmd.Flags().GetInt("cycle")
+		if cycle < 0 {
+			headCycle, err := rpc.GetHeadCycle()
+			util.AssertEE(err, "Failed to get current cycle!", constants.ExitExternalError)
+			cycle = headCycle - 1
+		}
+
+		result := performanceResult{Cycle: cycle, Bakers: make([]*node.BakerPerformance, 0, len(delegates))}
+		addError := func(err error) {
+			result.Errors = append(result.Errors, err.Error())
+			log.Warn(err.Error())
+		}
+
+		result.Levels, err = rpc.GetCycleLevels(cycle)
+		if err != nil {
+			if tzktUrl == "" {
+				addError(fmt.Errorf("failed to get cycle levels from node and tzkt is disabled - %s", err.Error()))
+			} else {
+				addError(fmt.Errorf("failed to get cycle levels from node, using tzkt - %s", err.Error()))
+				if result.Levels, err = getTzktCycleLevels(tzktUrl, cycle); err != nil {
+					addError(fmt.Errorf("failed to get cycle levels from tzkt - %s", err.Error()))
+				}
+			}
+		}
+
+		for _, delegate := range delegates {
+			var performance *node.BakerPerformance
+			if result.Levels != nil {
+				performance, err = rpc.GetBakerPerformance(delegate, result.Levels)
+				if err != nil {
+					addError(fmt.Errorf("failed to get performance of %s from node - %s", delegate, err.Error()))
+				}
+			}
+			if tzktUrl == "" {
+				if performance == nil {
+					addError(fmt.Errorf("performance of %s is not available from node and tzkt is disabled", delegate))
+					continue
+				}
+				log.Debug("tzkt is disabled, levels of missed attestations are not available", "delegate", delegate)
+				result.Bakers = append(result.Bakers, performance)
+				continue
+			}
+			if performance == nil {
+				if performance, err = getTzktBakerPerformance(tzktUrl, delegate, cycle); err != nil {
+					addError(fmt.Errorf("failed to get performance of %s from tzkt - %s", delegate, err.Error()))
+					continue
+				}
+			}
+			if performance.IncludedAttestations < performance.ExpectedAttestations {
+				if performance.MissedAttestations, err = getTzktMissedAttestations(tzktUrl, delegate, cycle); err != nil {
+					addError(fmt.Errorf("failed to get missed attestations of %s from tzkt - %s", delegate, err.Error()))
+				}
+			}
+			result.Bakers = append(result.Bakers, performance)
+		}
+
+		result.ServiceDowntimes = make([]serviceDowntime, 0)
+		if result.Levels != nil {
+			events, err := loadServiceHistory()
+			if err != nil {
+				addError(fmt.Errorf("failed to load service history - %s", err.Error()))
+			}
+			result.ServiceDowntimes = lo.Filter(getServiceDowntimes(events, result.Levels.FirstTime, result.Levels.LastTime), func(downtime serviceDowntime, _ int) bool {
+				return isBakingDowntime(downtime)
+			})
+		}
+		result.MissedBlocks = correlateMissedBlocks(result.Bakers, result.ServiceDowntimes)
+		result.MissedAttestations = correlateMissedAttestations(result.Bakers, result.ServiceDowntimes)
+
+		if cli.JsonLogFormat {
+			output, err := json.Marshal(result)
+			util.AssertEE(err, "Failed to serialize performance!", constants.ExitSerializationFailed)
+			fmt.Println(string(output))
+			return
+		}
+
+		if result.Levels != nil {
+			fmt.Printf("Cycle %d (levels %d-%d, %s - %s)\n", cycle, result.Levels.First, result.Levels.Last,
+				result.Levels.FirstTime.Local().Format(time.DateTime), result.Levels.LastTime.Local().Format(time.DateTime))
+		}
+		performanceTable := table.NewWriter()
+		performanceTable.SetStyle(table.StyleLight)
+		performanceTable.SetOutputMirror(os.Stdout)
+		performanceTable.AppendHeader(table.Row{"Delegate", "Blocks", "Attestations", "DAL Attestations", "Source"})
+		for _, baker := range result.Bakers {
+			dalAttestations := "-"
+			if baker.DalAttestableSlots != nil {
+				dalAttestations = formatPerformanceRate(*baker.DalAttestedSlots, *baker.DalAttestableSlots)
+			}
+			performanceTable.AppendRow(table.Row{baker.Delegate, formatPerformanceRate(baker.BakedBlocks, baker.ExpectedBlocks),
+				formatPerformanceRate(baker.IncludedAttestations, baker.ExpectedAttestations), dalAttestations, baker.Source})
+		}
+		performanceTable.Render()
+
+		if len(result.MissedBlocks) > 0 {
+			missedTable := table.NewWriter()
+			missedTable.SetStyle(table.StyleLight)
+			missedTable.SetOutputMirror(os.Stdout)
+			missedTable.AppendHeader(table.Row{"Delegate", "Missed Level", "Time", "Tezbake Downtime"})
+			for _, missed := range result.MissedBlocks {
+				missedTable.AppendRow(table.Row{missed.Delegate, missed.Level, missed.Time.Local().Format(time.DateTime), formatDowntime(missed.Downtime)})
+			}
+			missedTable.Render()
+		}
+		for _, baker := range result.Bakers {
+			missed := lo.Filter(result.MissedAttestations, func(missed missedAttestationReport, _ int) bool { return missed.Delegate == baker.Delegate })
+			if len(missed) == 0 {
+				continue
+			}
+			duringDowntime := lo.CountBy(missed, func(missed missedAttestationReport) bool { return missed.Downtime != nil })
+			fmt.Printf("%s missed attestations at %d levels, %d of them during tezbake downtimes\n", baker.Delegate, len(missed), duringDowntime)
+		}
+		for _, downtime := range result.ServiceDowntimes {
+			fmt.Printf("Services down through tezbake: %s\n", formatDowntime(&downtime))
+		}
+	},
+}
+
+func init() {
+	performanceCmd.Flags().Int("cycle", -1, "Cycle to report, defaults to the last completed cycle.")
+	performanceCmd.Flags().StringSlice("key", []string{}, "Baker key to report (can be repeated), defaults to baker keys of the node.")
+	addTzktFlags(performanceCmd, "TzKT api used for missed attestations and if the node does not keep context of the cycle", "Collects performance from node rpc only.")
+	performanceCmd.Flags().Int("timeout", 30, "How long to wait for each request.")
+	RootCmd.AddCommand(performanceCmd)
+}
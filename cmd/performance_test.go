@@ -0,0 +1,93 @@
+package cmd
+
+import (
+	"testing"
+	"time"
+
+	"github.com/tez-capital/tezbake/apps/node"
+)
+
+func TestCorrelateMissedBlocks(t *testing.T) {
+	start := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	events := []serviceEvent{
+		{Time: start.Add(-time.Hour), Action: ServiceEventStop, Apps: []string{"pay"}},
+		{Time: start.Add(-30 * time.Minute), Action: ServiceEventStart, Apps: []string{"pay"}},
+		{Time: start.Add(time.Hour), Action: ServiceEventUpgradeStarted, Apps: []string{"node", "signer"}},
+		{Time: start.Add(time.Hour + 5*time.Minute), Action: ServiceEventUpgradeFinished, Apps: []string{"node", "signer"}},
+		{Time: start.Add(3 * time.Hour), Action: ServiceEventStop, Apps: []string{"node"}},
+	}
+
+	downtimes := getServiceDowntimes(events, start, start.Add(4*time.Hour))
+	if len(downtimes) != 3 {
+		t.Fatalf("expected 3 downtimes in range, got %+v", downtimes)
+	}
+	if downtimes[len(downtimes)-1].End != nil {
+		t.Errorf("last downtime should be open")
+	}
+
+	bakers := []*node.BakerPerformance{{
+		Delegate: "tz1a",
+		MissedBlocks: []node.MissedBlock{
+			{Level: 10, Time: start.Add(time.Hour + 2*time.Minute)},
+			{Level: 20, Time: start.Add(2 * time.Hour)},
+			{Level: 30, Time: start.Add(3*time.Hour + time.Minute)},
+		},
+	}}
+	reports := correlateMissedBlocks(bakers, downtimes)
+	if reports[0].Downtime == nil || reports[0].Downtime.Action != ServiceEventUpgradeStarted {
+		t.Errorf("expected missed block during upgrade, got %+v", reports[0].Downtime)
+	}
+	if reports[1].Downtime != nil {
+		t.Errorf("expected no downtime, got %+v", reports[1].Downtime)
+	}
+	if reports[2].Downtime == nil || reports[2].Downtime.Action != ServiceEventStop {
+		t.Errorf("expected missed block during stop, got %+v", reports[2].Downtime)
+	}
+}
+
+func TestGetServiceDowntimesPerApp(t *testing.T) {
+	start := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	events := []serviceEvent{
+		{Time: start, Action: ServiceEventStop, Apps: []string{"node"}},
+		{Time: start.Add(time.Minute), Action: ServiceEventStart, Apps: []string{"pay"}},
+		{Time: start.Add(2 * time.Minute), Action: ServiceEventUpgradeStarted, Apps: []string{"node", "signer"}},
+		{Time: start.Add(3 * time.Minute), Action: ServiceEventStart, Apps: []string{"node", "signer"}},
+	}
+
+	downtimes := getServiceDowntimes(events, start, start.Add(time.Hour))
+	if len(downtimes) != 2 {
+		t.Fatalf("expected 2 downtimes, got %+v", downtimes)
+	}
+	nodeDowntime := downtimes[0]
+	if nodeDowntime.Apps[0] != "node" || nodeDowntime.Action != ServiceEventStop || nodeDowntime.End == nil || !nodeDowntime.End.Equal(start.Add(3*time.Minute)) {
+		t.Errorf("node downtime should last from stop to start, got %+v", nodeDowntime)
+	}
+	signer := downtimes[1]
+	if signer.Apps[0] != "signer" || !signer.Start.Equal(start.Add(2*time.Minute)) || signer.End == nil {
+		t.Errorf("signer downtime should start with upgrade, got %+v", signer)
+	}
+}
+
+func TestCorrelateMissedAttestations(t *testing.T) {
+	start := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	end := start.Add(10 * time.Minute)
+	downtimes := []serviceDowntime{{Start: start, End: &end, Action: ServiceEventStop, Apps: []string{"signer"}}}
+
+	bakers := []*node.BakerPerformance{{
+		Delegate: "tz1a",
+		MissedAttestations: []node.MissedAttestation{
+			{Level: 10, Time: start.Add(time.Minute), Slots: 5},
+			{Level: 20, Time: start.Add(time.Hour), Slots: 3},
+		},
+	}}
+	reports := correlateMissedAttestations(bakers, downtimes)
+	if len(reports) != 2 {
+		t.Fatalf("expected 2 reports, got %+v", reports)
+	}
+	if reports[0].Downtime == nil || reports[0].Slots != 5 {
+		t.Errorf("expected missed attestation during stop, got %+v", reports[0])
+	}
+	if reports[1].Downtime != nil {
+		t.Errorf("expected no downtime, got %+v", reports[1].Downtime)
+	}
+}
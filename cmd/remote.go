@@ -0,0 +1,360 @@
+package cmd
+
+import (
+	"encoding/base64"
+	"fmt"
+	"os"
+	"path"
+	"strings"
+
+	"github.com/samber/lo"
+	"github.com/tez-capital/tezbake/ami"
+	"github.com/tez-capital/tezbake/apps"
+	"github.com/tez-capital/tezbake/apps/base"
+	"github.com/tez-capital/tezbake/constants"
+	"github.com/tez-capital/tezbake/system"
+	"github.com/tez-capital/tezbake/util"
+	"go.alis.is/common/log"
+
+	shellquote "github.com/kballard/go-shellquote"
+	"github.com/spf13/cobra"
+)
+
+// apps which can be installed on remote
+var remoteCapableApps = []base.BakeBuddyApp{apps.Node, apps.DalNode}
+
+type remoteAppLocator struct {
+	app     base.BakeBuddyApp
+	locator *ami.RemoteConfiguration
+}
+
+// getSelectedRemoteApps returns locators of selected remote apps, all remote apps if none selected
+func getSelectedRemoteApps(cmd *cobra.Command) []remoteAppLocator {
+	selected := lo.Filter(remoteCapableApps, func(app base.BakeBuddyApp, _ int) bool {
+		return util.GetCommandBoolFlagS(cmd, app.GetId())
+	})
+	if len(selected) == 0 {
+		selected = remoteCapableApps
+	}
+
+	result := make([]remoteAppLocator, 0, len(selected))
+	for _, app := range selected {
+		isRemote, locator := ami.IsRemoteApp(app.GetPath())
+		if !isRemote {
+			log.Debug("app is not remote, skipping", "app", app.GetId())
+			continue
+		}
+		result = append(result, remoteAppLocator{app: app, locator: locator})
+	}
+	util.AssertBE(len(result) > 0, "No remote apps found!", constants.ExitAppNotInstalled)
+	return result
+}
+
+var remoteCmd = &cobra.Command{
+	Use:   "remote",
+	Short: "Manages remote apps.",
+	Long:  "Manages apps running on remote machines.",
+}
+
+var remoteCredentialsCmd = &cobra.Command{
+	Use:   "credentials",
+	Short: "Manages elevation credentials of remote apps.",
+	Long:  "Manages credentials used to elevate on remote machines.",
+}
+
+var remoteCredentialsSetCmd = &cobra.Command{
+	Use:   "set [--source <source>]",
+	Short: "Sets elevation credentials.",
+	Long: `Sets elevation credentials of remote apps.
+
+Source can be one of:
+  file                        - password stored in elevate.enc.json (or elevate.json if no encryption password is provided)
+  env[:VARIABLE]              - password read from environment variable (default TEZBAKE_ELEVATION_PASSWORD)
+  command:<command>           - first line of the command output, e.g. 'command:pass show bakery/node'
+  keyring[:service/account]   - password stored in the OS keyring
+  vault:<path>[#field]        - password read from Vault compatible KV endpoint (VAULT_ADDR, VAULT_TOKEN)
+  nopasswd                    - remote user elevates without password (see 'remote provision')`,
+	Run: func(cmd *cobra.Command, args []string) {
+		system.RequireElevatedUser()
+
+		source, err := ami.ParseElevationCredentialsSource(util.GetCommandStringFlagSD(cmd, "source", string(ami.CREDENTIALS_SOURCE_FILE)))
+		util.AssertEE(err, "Invalid elevation credentials source!", constants.ExitInvalidArgs)
+		elevate, err := ami.ParseRemoteElevationKind(util.GetCommandStringFlagS(cmd, "elevate"))
+		util.AssertEE(err, "Invalid elevation kind!", constants.ExitInvalidArgs)
+
+		for _, remote := range getSelectedRemoteApps(cmd) {
+			appDir := remote.app.GetPath()
+			locator := remote.locator
+			if elevate != ami.REMOTE_ELEVATION_NONE {
+				locator.Elevate = elevate
+			}
+			if locator.Elevate == ami.REMOTE_ELEVATION_NONE {
+				log.Warn("Remote does not use elevation, use --elevate to enable it. Skipping...", "app", remote.app.GetId())
+				continue
+			}
+
+			// prompt before removing old credentials so they are kept if the prompt fails
+			var credentials *ami.RemoteElevateCredentials
+			if source.IsFile() {
+				password := util.RequirePasswordE(fmt.Sprintf("Enter password to use for elevation on %s remote:", remote.app.GetId()), "Remote elevate requires password!", constants.ExitInternalError)
+				credentials = &ami.RemoteElevateCredentials{Kind: locator.Elevate, Password: password}
+			}
+
+			err := locator.ClearElevationCredentials()
+			util.AssertEE(err, "Failed to remove old elevation credentials!", constants.ExitIOError)
+			if credentials != nil {
+				ami.WriteRemoteElevationCredentials(appDir, locator, credentials)
+			} else {
+				locator.CredentialsSource = source
+			}
+			ami.WriteRemoteLocator(appDir, locator, false)
+			log.Info("Elevation credentials set.", "app", remote.app.GetId(), "source", source.Kind)
+		}
+	},
+}
+
+var remoteCredentialsTestCmd = &cobra.Command{
+	Use:   "test",
+	Short: "Tests elevation credentials.",
+	Long:  "Resolves elevation credentials of remote apps and verifies them by elevating on the remote.",
+	Run: func(cmd *cobra.Command, args []string) {
+		system.RequireElevatedUser()
+
+		failed := false
+		for _, remote := range getSelectedRemoteApps(cmd) {
+			if remote.locator.Elevate == ami.REMOTE_ELEVATION_NONE {
+				log.Info("Remote does not use elevation.", "app", remote.app.GetId())
+				continue
+			}
+			if err := remote.locator.TestElevationCredentials(); err != nil {
+				log.Error("Elevation credentials test failed!", "app", remote.app.GetId(), "error", err.Error())
+				failed = true
+				continue
+			}
+			log.Info("Elevation credentials are valid.", "app", remote.app.GetId())
+		}
+		if failed {
+			os.Exit(constants.ExitInvalidRemoteCredentials)
+		}
+	},
+}
+
+var remoteCredentialsClearCmd = &cobra.Command{
+	Use:   "clear",
+	Short: "Clears elevation credentials.",
+	Long:  "Removes stored elevation credentials and credentials source of remote apps.",
+	Run: func(cmd *cobra.Command, args []string) {
+		system.RequireElevatedUser()
+
+		remotes := getSelectedRemoteApps(cmd)
+		if !util.GetCommandBoolFlagS(cmd, "confirm") {
+			appIds := lo.Map(remotes, func(remote remoteAppLocator, _ int) string { return remote.app.GetId() })
+			util.ConfirmOrExit(fmt.Sprintf("Are you sure you want to clear elevation credentials of %v?", appIds), false, "Failed to confirm credentials removal!")
+		}
+
+		for _, remote := range remotes {
+			err := remote.locator.ClearElevationCredentials()
+			util.AssertEE(err, "Failed to remove elevation credentials!", constants.ExitIOError)
+			ami.WriteRemoteLocator(remote.app.GetPath(), remote.locator, false)
+			log.Info("Elevation credentials cleared.", "app", remote.app.GetId())
+		}
+	},
+}
+
+var remoteProvisionCmd = &cobra.Command{
+	Use:   "provision <user@host[:port]> [--node|--dal]",
+	Short: "Provisions remote host.",
+	Long: `Prepares a fresh linux host to run remote app.
+
+Logs in as the given admin user and
+  - installs dependencies and tezbake
+  - creates the user tezbake operates under (bb by default) and authorizes the app key
+  - allows the user to run tezbake as root without password (sudoers drop-in)
+    NOTE: tezbake can run any command as root, so the user effectively has full root access
+  - optionally disables ssh password authentication
+A fresh session as the new user is verified before the locator is written.
+Run 'tezbake setup --node-remote bb@host' afterwards to install the app.`,
+	Args: cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		system.RequireElevatedUser()
+
+		app := base.BakeBuddyApp(apps.Node)
+		if util.GetCommandBoolFlagS(cmd, apps.DalNode.GetId()) {
+			app = apps.DalNode
+		}
+		if app.IsInstalled() && !app.IsRemoteApp() {
+			log.Error("App is already installed locally. Please remove it first!", "app", app.GetId())
+			os.Exit(constants.ExitNotSupported)
+		}
+
+		_, err := ami.ProvisionRemote(&ami.ProvisionOptions{
+			AppDir:              app.GetPath(),
+			App:                 app.GetId(),
+			Remote:              args[0],
+			Auth:                util.GetCommandStringFlagS(cmd, "auth"),
+			User:                util.GetCommandStringFlagS(cmd, "user"),
+			DisablePasswordAuth: util.GetCommandBoolFlagS(cmd, "disable-password-auth"),
+		})
+		util.AssertEE(err, "Failed to provision remote!", constants.ExitExternalError)
+	},
+}
+
+// parseRemotePathSpec parses <app>:<path> into remote app locator and path
+func parseRemotePathSpec(spec string) (remoteAppLocator, string) {
+	appId, remotePath, found := strings.Cut(spec, ":")
+	util.AssertBE(found && remotePath != "", fmt.Sprintf("Invalid remote path '%s', expected <app>:<path>!", spec), constants.ExitInvalidArgs)
+	app, found := lo.Find(remoteCapableApps, func(app base.BakeBuddyApp) bool { return app.GetId() == appId })
+	util.AssertBE(found, fmt.Sprintf("App '%s' can not be remote!", appId), constants.ExitInvalidArgs)
+	isRemote, locator := ami.IsRemoteApp(app.GetPath())
+	util.AssertBE(isRemote, fmt.Sprintf("App '%s' is not remote!", appId), constants.ExitAppNotInstalled)
+	return remoteAppLocator{app: app, locator: locator}, remotePath
+}
+
+func openRemoteSession(remote remoteAppLocator) *ami.TezbakeRemoteSession {
+	session, err := remote.locator.OpenAppRemoteSession()
+	util.AssertEE(err, "Failed to connect to remote!", constants.ExitExternalError)
+	return session
+}
+
+var remotePullCmd = &cobra.Command{
+	Use:   "pull <app>:<remote path> [local path]",
+	Short: "Downloads files from remote.",
+	Long: `Downloads file or directory from remote app, e.g. logs, configuration or snapshot.
+
+Relative remote paths are resolved against the app directory on the remote.
+If the remote user can not read the path, it is copied with elevation.
+
+Examples:
+  tezbake remote pull node:data/config.json ./
+  tezbake remote pull node:/var/log/syslog ./remote-syslog`,
+	Args: cobra.RangeArgs(1, 2),
+	Run: func(cmd *cobra.Command, args []string) {
+		remote, remotePath := parseRemotePathSpec(args[0])
+		localPath := "."
+		if len(args) > 1 {
+			localPath = args[1]
+		}
+
+		session := openRemoteSession(remote)
+		defer session.Close()
+
+		log.Info("Downloading...", "app", remote.app.GetId(), "remote", session.ResolveRemotePath(remotePath), "local", localPath)
+		err := session.Pull(remotePath, localPath, util.GetCommandBoolFlagS(cmd, "elevate"))
+		util.AssertEE(err, "Failed to download from remote!", constants.ExitExternalError)
+		log.Info("Download finished.")
+	},
+}
+
+var remotePushCmd = &cobra.Command{
+	Use:   "push <local path> <app>:<remote path>",
+	Short: "Uploads files to remote.",
+	Long: `Uploads file or directory to remote app, e.g. snapshot to import.
+
+Relative remote paths are resolved against the app directory on the remote.
+If the remote user can not write to the path, it is copied in place with elevation
+and ownership of the destination directory is applied.
+
+Example:
+  tezbake remote push ./mainnet.rolling node:snapshot.rolling`,
+	Args: cobra.ExactArgs(2),
+	Run: func(cmd *cobra.Command, args []string) {
+		localPath := args[0]
+		_, err := os.Stat(localPath)
+		util.AssertEE(err, "Failed to access local path!", constants.ExitIOError)
+		remote, remotePath := parseRemotePathSpec(args[1])
+
+		session := openRemoteSession(remote)
+		defer session.Close()
+
+		log.Info("Uploading...", "app", remote.app.GetId(), "local", localPath, "remote", session.ResolveRemotePath(remotePath))
+		err = session.Push(localPath, remotePath, util.GetCommandBoolFlagS(cmd, "elevate"))
+		util.AssertEE(err, "Failed to upload to remote!", constants.ExitExternalError)
+		log.Info("Upload finished.")
+	},
+}
+
+// getRemoteShellCommand builds command which enters the remote app directory and starts a shell
+// with the same prompt as 'tezbake instance'
+func getRemoteShellCommand(remote remoteAppLocator, elevate bool) string {
+	alias := fmt.Sprintf("%s@%s", remote.app.GetId(), remote.locator.Host)
+	bashPrompt, bashArgs := getInstancePrompt("bash", alias)
+	shPrompt, _ := getInstancePrompt("sh", alias)
+	appPath := path.Join(remote.locator.InstancePath, remote.locator.App)
+
+	script := strings.Join([]string{
+		fmt.Sprintf("export TEZBAKE_INSTANCE_PATH=%s", shellquote.Join(remote.locator.InstancePath)),
+		fmt.Sprintf("cd %s 2>/dev/null || cd %s 2>/dev/null", shellquote.Join(appPath), shellquote.Join(remote.locator.InstancePath)),
+		fmt.Sprintf("if command -v bash >/dev/null 2>&1; then PS1=%s exec bash %s -i; fi", shellquote.Join(bashPrompt), shellquote.Join(bashArgs...)),
+		fmt.Sprintf("PS1=%s exec sh -i", shellquote.Join(shPrompt)),
+	}, "\n")
+	command := shellquote.Join("sh", "-c", script)
+	if !elevate {
+		return command
+	}
+	// remote tezbake elevates interactively, password is prompted on the remote unless elevation is passwordless
+	encoded := base64.StdEncoding.EncodeToString([]byte(command))
+	if remote.locator.Elevate == ami.REMOTE_ELEVATION_SU {
+		return shellquote.Join("su", "-c", fmt.Sprintf("tezbake execute --base64 %s", encoded))
+	}
+	return fmt.Sprintf("tezbake execute --elevate --base64 %s", encoded)
+}
+
+var remoteShellCmd = &cobra.Command{
+	Use:   "shell [--node|--dal] [--elevate]",
+	Short: "Opens shell on remote.",
+	Long: `Opens interactive shell on the remote app host using the key and connection details of the app locator.
+
+The shell starts in the app directory on the remote. With --elevate the shell runs as root,
+the elevation password is prompted on the remote unless the user elevates without password.`,
+	Args: cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		remotes := getSelectedRemoteApps(cmd)
+		util.AssertBE(len(remotes) == 1, "Multiple remote apps found, please select one with --node or --dal!", constants.ExitInvalidArgs)
+		remote := remotes[0]
+
+		elevate := util.GetCommandBoolFlagS(cmd, "elevate")
+		util.AssertBE(!elevate || remote.locator.Elevate != ami.REMOTE_ELEVATION_NONE, "Remote does not use elevation!", constants.ExitNotSupported)
+
+		session := openRemoteSession(remote)
+		exitCode, err := session.OpenShell(getRemoteShellCommand(remote, elevate))
+		session.Close()
+		util.AssertEE(err, "Failed to open remote shell!", constants.ExitExternalError)
+		os.Exit(exitCode)
+	},
+}
+
+func init() {
+	for _, app := range remoteCapableApps {
+		remoteShellCmd.Flags().Bool(app.GetId(), false, fmt.Sprintf("Opens shell on %s remote.", app.GetId()))
+	}
+	remoteShellCmd.Flags().Bool("elevate", false, "Opens shell as root.")
+	remoteCmd.AddCommand(remoteShellCmd)
+
+	remotePullCmd.Flags().Bool("elevate", false, "Always copy with elevation.")
+	remotePushCmd.Flags().Bool("elevate", false, "Always copy with elevation.")
+	remoteCmd.AddCommand(remotePullCmd)
+	remoteCmd.AddCommand(remotePushCmd)
+
+	for _, app := range remoteCapableApps {
+		remoteProvisionCmd.Flags().Bool(app.GetId(), false, fmt.Sprintf("Provisions remote for %s.", app.GetId()))
+	}
+	remoteProvisionCmd.Flags().String("auth", "pass", "pass|key:<path to key> used to log in as admin user")
+	remoteProvisionCmd.Flags().String("user", constants.DefaultRemoteUser, "User tezbake operates under on the remote.")
+	remoteProvisionCmd.Flags().Bool("disable-password-auth", false, "Disables ssh password authentication on the remote.")
+	remoteCmd.AddCommand(remoteProvisionCmd)
+
+	for _, app := range remoteCapableApps {
+		remoteCredentialsCmd.PersistentFlags().Bool(app.GetId(), false, fmt.Sprintf("Manages %s credentials.", app.GetId()))
+	}
+
+	remoteCredentialsSetCmd.Flags().String("source", string(ami.CREDENTIALS_SOURCE_FILE), "Source of the elevation password.")
+	remoteCredentialsSetCmd.Flags().String("elevate", "", "Changes kind of elevation (sudo or su).")
+	remoteCredentialsClearCmd.Flags().Bool("confirm", false, "Skips confirmation.")
+
+	remoteCredentialsCmd.AddCommand(remoteCredentialsSetCmd)
+	remoteCredentialsCmd.AddCommand(remoteCredentialsTestCmd)
+	remoteCredentialsCmd.AddCommand(remoteCredentialsClearCmd)
+	remoteCmd.AddCommand(remoteCredentialsCmd)
+
+	RootCmd.AddCommand(remoteCmd)
+}
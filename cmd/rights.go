@@ -0,0 +1,211 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/samber/lo"
+	"github.com/tez-capital/tezbake/apps"
+	"github.com/tez-capital/tezbake/apps/node"
+	"github.com/tez-capital/tezbake/cli"
+	"github.com/tez-capital/tezbake/constants"
+	"github.com/tez-capital/tezbake/util"
+	"go.alis.is/common/log"
+
+	"github.com/jedib0t/go-pretty/v6/table"
+	"github.com/spf13/cobra"
+)
+
+type delegateDuties struct {
+	Delegate        string     `json:"delegate"`
+	NextBaking      *node.Duty `json:"next_baking,omitempty"`
+	Bakings         int        `json:"bakings"`
+	NextAttestation *node.Duty `json:"next_attestation,omitempty"`
+	Attestations    int        `json:"attestations"`
+	DalAttestations int        `json:"dal_attestations"`
+}
+
+type maintenanceWindow struct {
+	FromLevel int           `json:"from_level"`
+	ToLevel   int           `json:"to_level"`
+	Start     time.Time     `json:"start"`
+	End       time.Time     `json:"end"`
+	Duration  time.Duration `json:"duration"`
+}
+
+type rightsResult struct {
+	*node.Rights
+	Delegates         []delegateDuties   `json:"delegates"`
+	MaintenanceWindow *maintenanceWindow `json:"maintenance_window,omitempty"`
+}
+
+// getRightsDelegates returns baker keys of the node - baker and additional baking keys
+func getRightsDelegates() ([]string, error) {
+	delegates, err := getNodeBakers()
+	if err != nil {
+		return nil, err
+	}
+	nodeInfo, err := apps.Node.GetInfoFromOptions(&node.InfoCollectionOptions{Keys: true})
+	if err != nil {
+		log.Warn("Failed to collect additional baking keys!", "error", err.Error())
+	}
+	delegates = append(delegates, lo.Values(nodeInfo.AdditionalBakingKeys)...)
+	return lo.Uniq(lo.Compact(delegates)), nil
+}
+
+// resolveRightsDelegates resolves keys (which may be consensus or companion keys) to delegates rights are assigned to,
+// keys which can not be resolved are kept as they are
+func resolveRightsDelegates(rpc *node.RpcClient, keys []string) []string {
+	resolver := rpc.NewAttestationProfileResolver()
+	return lo.Uniq(lo.Map(keys, func(key string, _ int) string {
+		delegate, err := resolver.Resolve(key)
+		if err != nil {
+			log.Warn("Failed to resolve key to delegate, using the key as delegate.", "key", key, "error", err.Error())
+			return key
+		}
+		return delegate
+	}))
+}
+
+func summarizeDelegateDuties(rights *node.Rights, delegates []string) []delegateDuties {
+	return lo.Map(delegates, func(delegate string, _ int) delegateDuties {
+		summary := delegateDuties{Delegate: delegate, DalAttestations: rights.DalAttestations[delegate]}
+		for _, duty := range rights.Duties {
+			if duty.Delegate != delegate {
+				continue
+			}
+			switch duty.Kind {
+			case node.DutyKindBaking:
+				if summary.NextBaking == nil {
+					summary.NextBaking = &duty
+				}
+				summary.Bakings++
+			case node.DutyKindAttestation:
+				if summary.NextAttestation == nil {
+					summary.NextAttestation = &duty
+				}
+				summary.Attestations++
+			}
+		}
+		return summary
+	})
+}
+
+// getIdleWindows returns ranges of levels after the head without any duty,
+// times are estimated from the head time and minimal block delay
+func getIdleWindows(rights *node.Rights) []maintenanceWindow {
+	blockDelay := time.Duration(rights.MinimalBlockDelay) * time.Second
+	levels := lo.Uniq(lo.Map(rights.Duties, func(duty node.Duty, _ int) int { return duty.Level }))
+	// sentinel duties around the collected range, the head is already baked
+	levels = append(append([]int{rights.HeadLevel}, levels...), rights.LastLevel+1)
+
+	windows := make([]maintenanceWindow, 0)
+	for i := 1; i < len(levels); i++ {
+		from, to := levels[i-1]+1, levels[i]-1
+		if to < from {
+			continue
+		}
+		// window starts after the last duty block and ends before the next duty block
+		start := rights.HeadTime.Add(time.Duration(from-1-rights.HeadLevel) * blockDelay)
+		end := rights.HeadTime.Add(time.Duration(to-rights.HeadLevel) * blockDelay)
+		windows = append(windows, maintenanceWindow{FromLevel: from, ToLevel: to, Start: start, End: end, Duration: end.Sub(start)})
+	}
+	return windows
+}
+
+// findMaintenanceWindow finds the longest range of levels after the head without any duty
+func findMaintenanceWindow(rights *node.Rights) *maintenanceWindow {
+	windows := getIdleWindows(rights)
+	if len(windows) == 0 {
+		return nil
+	}
+	window := lo.MaxBy(windows, func(a, b maintenanceWindow) bool { return a.ToLevel-a.FromLevel > b.ToLevel-b.FromLevel })
+	return &window
+}
+
+func formatDuty(duty *node.Duty, now time.Time) string {
+	if duty == nil {
+		return "-"
+	}
+	return fmt.Sprintf("%d (in %s)", duty.Level, duty.EstimatedTime.Sub(now).Round(time.Second))
+}
+
+var rightsCmd = &cobra.Command{
+	Use:   "rights [--cycles <count>] [--key <pkh>...] [--maintenance-window]",
+	Short: "Prints upcoming baking and attestation rights.",
+	Long: `Queries rpc of the node for baking (round 0) and attestation rights of baker keys of the node
+in the current and following cycles and prints next duties relative to the chain head.
+Consensus and companion keys are resolved to their delegates through rpc of the node.
+
+With --maintenance-window the longest range of levels without any duty is printed - the safest time
+for upgrades and restarts.`,
+	Args: cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		util.AssertBE(apps.Node.IsInstalled(), "Node is not installed!", constants.ExitAppNotInstalled)
+		cycles, _ := cmd.Flags().GetInt("cycles")
+		util.AssertBE(cycles > 0, "Cycles has to be positive!", constants.ExitInvalidArgs)
+		timeout, _ := cmd.Flags().GetInt("timeout")
+
+		delegates, _ := cmd.Flags().GetStringSlice("key")
+		if len(delegates) == 0 {
+			var err error
+			delegates, err = getRightsDelegates()
+			util.AssertEE(err, "Failed to get baker keys!", constants.ExitExternalError)
+		}
+		util.AssertBE(len(delegates) > 0, "No baker keys found!", constants.ExitInvalidArgs)
+
+		rpc, err := apps.Node.NewRpcClient(time.Duration(timeout) * time.Second)
+		util.AssertEE(err, "Failed to connect to node rpc!", constants.ExitExternalError)
+		defer rpc.Close()
+		delegates = resolveRightsDelegates(rpc, delegates)
+		rights, err := rpc.GetRights(delegates, cycles)
+		util.AssertEE(err, "Failed to collect rights!", constants.ExitExternalError)
+		for _, rightsError := range rights.Errors {
+			log.Warn(rightsError)
+		}
+
+		result := rightsResult{Rights: rights, Delegates: summarizeDelegateDuties(rights, delegates)}
+		if util.GetCommandBoolFlagS(cmd, "maintenance-window") {
+			result.MaintenanceWindow = findMaintenanceWindow(rights)
+		}
+
+		if cli.JsonLogFormat {
+			output, err := json.Marshal(result)
+			util.AssertEE(err, "Failed to serialize rights!", constants.ExitSerializationFailed)
+			fmt.Println(string(output))
+			return
+		}
+
+		now := time.Now()
+		fmt.Printf("Head level %d (cycle %d), rights collected up to level %d\n", rights.HeadLevel, rights.Cycle, rights.LastLevel)
+		rightsTable := table.NewWriter()
+		rightsTable.SetStyle(table.StyleLight)
+		rightsTable.SetOutputMirror(os.Stdout)
+		rightsTable.AppendHeader(table.Row{"Delegate", "Next Baking", "Bakings", "Next Attestation", "Attestations", "DAL Attestations"})
+		for _, summary := range result.Delegates {
+			rightsTable.AppendRow(table.Row{summary.Delegate, formatDuty(summary.NextBaking, now), summary.Bakings, formatDuty(summary.NextAttestation, now), summary.Attestations, summary.DalAttestations})
+		}
+		rightsTable.Render()
+
+		if util.GetCommandBoolFlagS(cmd, "maintenance-window") {
+			window := result.MaintenanceWindow
+			if window == nil {
+				fmt.Println("There is no level without duties in the collected cycles.")
+				return
+			}
+			fmt.Printf("Longest window without duties: levels %d-%d, from %s to %s (%s, starts in %s)\n",
+				window.FromLevel, window.ToLevel, window.Start.Local().Format(time.DateTime), window.End.Local().Format(time.DateTime),
+				window.Duration, window.Start.Sub(now).Round(time.Second))
+		}
+	},
+}
+
+func init() {
+	rightsCmd.Flags().Int("cycles", 2, "Number of cycles to collect rights for, starting with the current one.")
+	rightsCmd.Flags().StringSlice("key", []string{}, "Baker key to collect rights for (can be repeated), defaults to baker keys of the node.")
+	rightsCmd.Flags().Bool("maintenance-window", false, "Prints the longest window without duties.")
+	rightsCmd.Flags().Int("timeout", 30, "How long to wait for each rpc request.")
+	RootCmd.AddCommand(rightsCmd)
+}
@@ -0,0 +1,61 @@
+package cmd
+
+import (
+	"testing"
+	"time"
+
+	"github.com/tez-capital/tezbake/apps/node"
+)
+
+func TestFindMaintenanceWindow(t *testing.T) {
+	headTime := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	rights := &node.Rights{
+		HeadLevel:         100,
+		HeadTime:          headTime,
+		LastLevel:         120,
+		MinimalBlockDelay: 8,
+		Duties: []node.Duty{
+			{Delegate: "tz1a", Kind: node.DutyKindAttestation, Level: 102},
+			{Delegate: "tz1a", Kind: node.DutyKindBaking, Level: 103},
+			{Delegate: "tz1b", Kind: node.DutyKindAttestation, Level: 103},
+			{Delegate: "tz1a", Kind: node.DutyKindAttestation, Level: 110},
+			{Delegate: "tz1a", Kind: node.DutyKindAttestation, Level: 115},
+		},
+	}
+
+	window := findMaintenanceWindow(rights)
+	if window == nil {
+		t.Fatal("expected maintenance window")
+	}
+	if window.FromLevel != 104 || window.ToLevel != 109 {
+		t.Errorf("unexpected window levels %d-%d", window.FromLevel, window.ToLevel)
+	}
+	if !window.Start.Equal(headTime.Add(3*8*time.Second)) || window.Duration != 6*8*time.Second {
+		t.Errorf("unexpected window time %s (%s)", window.Start, window.Duration)
+	}
+
+	// duties in every level
+	rights.LastLevel = 103
+	rights.Duties = []node.Duty{{Level: 101}, {Level: 102}, {Level: 103}}
+	if window := findMaintenanceWindow(rights); window != nil {
+		t.Errorf("expected no window, got %d-%d", window.FromLevel, window.ToLevel)
+	}
+}
+
+func TestSummarizeDelegateDuties(t *testing.T) {
+	rights := &node.Rights{
+		Duties: []node.Duty{
+			{Delegate: "tz1a", Kind: node.DutyKindAttestation, Level: 102},
+			{Delegate: "tz1a", Kind: node.DutyKindBaking, Level: 103},
+			{Delegate: "tz1a", Kind: node.DutyKindAttestation, Level: 104},
+		},
+		DalAttestations: map[string]int{"tz1a": 2},
+	}
+	summaries := summarizeDelegateDuties(rights, []string{"tz1a", "tz1b"})
+	if summaries[0].NextAttestation.Level != 102 || summaries[0].Attestations != 2 || summaries[0].Bakings != 1 || summaries[0].DalAttestations != 2 {
+		t.Errorf("unexpected summary %+v", summaries[0])
+	}
+	if summaries[1].NextBaking != nil || summaries[1].Attestations != 0 {
+		t.Errorf("unexpected summary %+v", summaries[1])
+	}
+}
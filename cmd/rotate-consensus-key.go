@@ -0,0 +1,259 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/samber/lo"
+	"github.com/tez-capital/tezbake/ami"
+	"github.com/tez-capital/tezbake/apps"
+	"github.com/tez-capital/tezbake/cli"
+	"github.com/tez-capital/tezbake/constants"
+	"github.com/tez-capital/tezbake/system"
+	"github.com/tez-capital/tezbake/util"
+	"go.alis.is/common/log"
+
+	"github.com/spf13/cobra"
+)
+
+type pendingKeysResponse struct {
+	Pendings []struct {
+		Cycle int    `json:"cycle"`
+		Pkh   string `json:"pkh"`
+	} `json:"pendings"`
+}
+
+// keyInclusionPollInterval is roughly a block time
+const keyInclusionPollInterval = 10 * time.Second
+
+type keyRotationResult struct {
+	Baker           string   `json:"baker"`
+	Role            KeyRole  `json:"role"`
+	Alias           string   `json:"alias"`
+	Pkh             string   `json:"pkh"`
+	ActivationCycle int      `json:"activation_cycle,omitempty"`
+	DalProfiles     []string `json:"dal_profiles,omitempty"`
+}
+
+// waitForKeyActivationCycle waits until the key update is included and returns the cycle the new key activates in
+func waitForKeyActivationCycle(bakerPkh string, role KeyRole, newPkh string, timeout time.Duration) (int, error) {
+	deadline := time.Now().Add(timeout)
+	log.Info("Waiting for the key update to be included...", "timeout", timeout.String())
+	for {
+		var pending pendingKeysResponse
+		err := apps.Signer.RpcGet(fmt.Sprintf("/chains/main/blocks/head/context/delegates/%s/%s_key", bakerPkh, role), &pending)
+		if err == nil {
+			for _, pendingKey := range pending.Pendings {
+				if pendingKey.Pkh == newPkh {
+					return pendingKey.Cycle, nil
+				}
+			}
+		}
+		if time.Now().Add(keyInclusionPollInterval).After(deadline) {
+			if err == nil {
+				err = fmt.Errorf("key update was not included within %s", timeout)
+			}
+			return 0, err
+		}
+		time.Sleep(keyInclusionPollInterval)
+	}
+}
+
+// ensureSignerRunning starts signer if needed and returns function restoring its previous state
+func ensureSignerRunning() func() {
+	wasSignerRunning, _ := apps.Signer.IsServiceStatus(constants.SignerAppServiceId, "running")
+	if !wasSignerRunning {
+		exitCode, err := apps.Signer.Start()
+		util.AssertEE(err, "Failed to start signer!", exitCode)
+
+		// Sleep 3 seconds to allow the signer service to start up
+		time.Sleep(3 * time.Second)
+	}
+
+	isSignerRunning, _ := apps.Signer.IsServiceStatus(constants.SignerAppServiceId, "running")
+	util.AssertBE(isSignerRunning, "Signer is not running. Please start signer services.", constants.ExitSignerNotOperational)
+	return func() {
+		if !wasSignerRunning {
+			apps.Signer.Stop()
+		}
+	}
+}
+
+// importRotationKey generates or imports the new key into the signer
+func importRotationKey(cmd *cobra.Command, kind string, keyAlias string) {
+	derivationPath := util.GetCommandStringFlagS(cmd, "derivation-path")
+	force := util.GetCommandBoolFlagS(cmd, "force")
+
+	var amiArgs []string
+	switch kind {
+	case "ledger":
+		amiArgs = []string{"setup-ledger", "--import-key"}
+		if derivationPath != "" {
+			amiArgs = []string{"setup-ledger", "--import-key=" + derivationPath}
+		}
+		if ledgerId := util.GetCommandStringFlagS(cmd, "ledger-id"); ledgerId != "" {
+			amiArgs = append(amiArgs, "--ledger-id="+ledgerId)
+		}
+	case "tezsign":
+		amiArgs = []string{"setup-tezsign", "--import-key"}
+		if derivationPath != "" {
+			amiArgs = []string{"setup-tezsign", "--import-key=" + derivationPath}
+		}
+	case "soft":
+		amiArgs = []string{"setup-soft-wallet"}
+		if importKey := util.GetCommandStringFlagS(cmd, "import-key"); importKey != "" {
+			amiArgs = append(amiArgs, "--import-key="+importKey)
+		} else {
+			amiArgs = append(amiArgs, "--generate="+util.GetCommandStringFlagSD(cmd, "generate", "bls"))
+		}
+	case "existing":
+		log.Info("Using key already present in signer.", "alias", keyAlias)
+		return
+	default:
+		util.AssertBE(false, fmt.Sprintf("Invalid key kind '%s'!", kind), constants.ExitInvalidArgs)
+	}
+	amiArgs = append(amiArgs, fmt.Sprintf("--key-alias=%s", keyAlias))
+	if force {
+		amiArgs = append(amiArgs, "--force")
+	}
+
+	// tezsign import requires running signer, ledger and soft wallet are imported with signer stopped
+	if kind == "tezsign" {
+		defer ensureSignerRunning()()
+	} else if wasRunning, _ := apps.Signer.IsServiceStatus(constants.SignerAppServiceId, "running"); wasRunning {
+		exitCode, err := apps.Signer.Stop()
+		util.AssertEE(err, "Failed to stop signer!", exitCode)
+		defer apps.Signer.Start()
+	}
+
+	log.Info("Importing new key to signer...", "kind", kind, "alias", keyAlias)
+	exitCode, err := apps.Signer.Execute(amiArgs...)
+	util.AssertEE(err, "Failed to import key to signer!", exitCode)
+	util.AssertBE(exitCode == 0, "Failed to import key to signer!", exitCode)
+}
+
+// addDalAttesterProfile adds attestation profile of the baker to configured dal profiles
+func addDalAttesterProfile(resolver *util.AttestationProfileResolver, bakerPkh string) []string {
+	bakerProfiles, err := resolveDalAttesterProfiles(resolver, []string{bakerPkh}, false, false)
+	util.AssertEE(err, "Failed to resolve attestation profile!", constants.ExitInternalError)
+	profiles, err := apps.DalNode.GetAttesterProfiles()
+	util.AssertEE(err, "Failed to load attester profiles!", constants.ExitAppConfigurationLoadFailed)
+	profiles = lo.Uniq(append(profiles, bakerProfiles...))
+	slices.Sort(profiles)
+	_, err = syncDalAttesterProfiles(profiles)
+	util.AssertEE(err, "Failed to update attester profiles!", constants.ExitExternalError)
+	return profiles
+}
+
+var rotateConsensusKeyCmd = &cobra.Command{
+	Use:   "rotate-consensus-key --kind <ledger|tezsign|soft|existing> [--companion]",
+	Short: "Rotates consensus or companion key.",
+	Long: `Guides through the consensus (or companion) key rotation:
+  1. generates or imports the new key into the signer (ledger, tezsign, soft or already existing alias)
+  2. submits update_consensus_key (update_companion_key) operation from the baker key
+  3. waits for the operation to be included and reports the cycle the new key activates in
+  4. imports the new key to the node so the baker bakes with it once it activates
+     (node services are restarted only with --restart-node or when confirmed)
+  5. refreshes DAL attester profiles
+Keep the old key available to the baker until the activation cycle.`,
+	Args: cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		util.AssertBE(apps.Signer.IsInstalled(), "Signer is not installed!", constants.ExitAppNotInstalled)
+		system.RequireElevatedUser()
+
+		role := KeyRoleConsensus
+		if util.GetCommandBoolFlagS(cmd, "companion") {
+			role = KeyRoleCompanion
+		}
+		kind := util.GetCommandStringFlagS(cmd, "kind")
+		keyAlias := util.GetCommandStringFlagSD(cmd, "key-alias", string(role))
+		bakerAlias := util.GetCommandStringFlagSD(cmd, "baker-alias", "baker")
+		util.AssertBE(keyAlias != bakerAlias, "New key alias must differ from baker alias!", constants.ExitInvalidArgs)
+		confirmed := util.GetCommandBoolFlagS(cmd, "confirm")
+
+		importRotationKey(cmd, kind, keyAlias)
+
+		stopSigner := ensureSignerRunning()
+		defer stopSigner()
+
+		bakerPkh, exitCode, err := apps.Signer.GetKeyHash(bakerAlias)
+		util.AssertEE(err, "Failed to get baker key hash!", exitCode)
+		newPkh, exitCode, err := apps.Signer.GetKeyHash(keyAlias)
+		util.AssertEE(err, "Failed to get new key hash!", exitCode)
+		result := keyRotationResult{Baker: bakerPkh, Role: role, Alias: keyAlias, Pkh: newPkh}
+
+		if !confirmed {
+			util.ConfirmOrExit(fmt.Sprintf("Submit update of %s key of %s to %s (%s)?", role, bakerPkh, newPkh, keyAlias), false, "Failed to confirm key rotation!")
+		}
+		log.Info("Submitting key update...", "role", role, "baker", bakerPkh, "key", newPkh)
+		exitCode, err = apps.Signer.Execute("client", "set", string(role), "key", "for", bakerAlias, "to", keyAlias)
+		util.AssertEE(err, "Failed to submit key update!", exitCode)
+		util.AssertBE(exitCode == 0, "Failed to submit key update!", exitCode)
+
+		inclusionTimeout, _ := cmd.Flags().GetDuration("inclusion-timeout")
+		activationCycle, err := waitForKeyActivationCycle(bakerPkh, role, newPkh, inclusionTimeout)
+		if err != nil {
+			log.Warn("Failed to determine activation cycle. The operation may not be included yet.", "error", err.Error())
+		}
+		result.ActivationCycle = activationCycle
+
+		if apps.Node.IsInstalled() {
+			log.Info("Importing new key to the node...")
+			// same as import-key of setup-ledger and setup-soft-wallet
+			ami.REMOTE_VARS[ami.BAKER_KEY_HASH_REMOTE_VAR] = newPkh
+			exitCode, err := apps.Node.Execute("import-key", newPkh, "--force", fmt.Sprintf("--alias=%s", keyAlias))
+			util.AssertEE(err, "Failed to import key to node!", exitCode)
+			util.AssertBE(exitCode == 0, "Failed to import key to node!", exitCode)
+			restartNode := util.GetCommandBoolFlagS(cmd, "restart-node")
+			if !restartNode && !confirmed {
+				restartNode = util.Confirm("Restart node services so the baker picks up the new key?", false, "Failed to confirm node restart!")
+			}
+			if restartNode {
+				exitCode, err := apps.Node.Stop()
+				util.AssertEE(err, "Failed to stop node!", exitCode)
+				exitCode, err = apps.Node.Start()
+				util.AssertEE(err, "Failed to start node!", exitCode)
+			} else {
+				log.Warn("Node services not restarted, baker will not use the new key until restarted!")
+			}
+		}
+
+		if apps.DalNode.IsInstalled() && !util.GetCommandBoolFlagS(cmd, "skip-dal") {
+			result.DalProfiles = addDalAttesterProfile(getAttestationProfileResolver(cmd), bakerPkh)
+		}
+
+		if cli.JsonLogFormat {
+			output, err := json.Marshal(result)
+			util.AssertEE(err, "Failed to serialize rotation result!", constants.ExitSerializationFailed)
+			fmt.Println(string(output))
+			return
+		}
+		if result.ActivationCycle > 0 {
+			log.Info("Key rotation submitted. Keep the old key available until the new key activates.", "key", newPkh, "activation_cycle", result.ActivationCycle)
+		} else {
+			log.Info("Key rotation submitted. Activation cycle is not known until the operation is included.", "key", newPkh)
+		}
+	},
+}
+
+func init() {
+	rotateConsensusKeyCmd.Flags().String("kind", "", "Kind of the new key - ledger, tezsign, soft or existing (alias already in signer).")
+	rotateConsensusKeyCmd.Flags().Bool("companion", false, "Rotates companion key instead of consensus key.")
+	rotateConsensusKeyCmd.Flags().String("key-alias", "", "Alias of the new key (defaults to consensus or companion).")
+	rotateConsensusKeyCmd.Flags().String("baker-alias", "baker", "Alias of the baker (manager) key.")
+	rotateConsensusKeyCmd.Flags().String("derivation-path", "", "Derivation path of the key to import (ledger and tezsign).")
+	rotateConsensusKeyCmd.Flags().String("ledger-id", "", "Ledger id to import key from.")
+	rotateConsensusKeyCmd.Flags().String("import-key", "", "Secret key to import (soft).")
+	rotateConsensusKeyCmd.Flags().String("generate", "bls", "Kind of key to generate (soft).")
+	rotateConsensusKeyCmd.Flags().Bool("skip-dal", false, "Does not refresh DAL attester profiles.")
+	rotateConsensusKeyCmd.Flags().Bool("confirm", false, "Skips confirmations (node services are not restarted unless --restart-node).")
+	rotateConsensusKeyCmd.Flags().Bool("restart-node", false, "Restarts node services so the baker picks up the new key.")
+	rotateConsensusKeyCmd.Flags().Duration("inclusion-timeout", 3*time.Minute, "How long to wait for the key update to be included.")
+	rotateConsensusKeyCmd.Flags().BoolP("force", "f", false, "Force key import. (overwrites existing)")
+	addAttestationProfileResolverFlags(rotateConsensusKeyCmd)
+	rotateConsensusKeyCmd.MarkFlagRequired("kind")
+
+	RootCmd.AddCommand(rotateConsensusKeyCmd)
+}
@@ -0,0 +1,138 @@
+package cmd
+
+import (
+	"bufio"
+	"encoding/json"
+	"os"
+	"path"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/samber/lo"
+	"github.com/tez-capital/tezbake/apps/base"
+	"github.com/tez-capital/tezbake/cli"
+	"go.alis.is/common/log"
+)
+
+// serviceHistoryFile records service stops and starts done through tezbake, used to explain missed duties
+const serviceHistoryFile = "service-history.jsonl"
+
+const (
+	ServiceEventStop              = "stop"
+	ServiceEventStart             = "start"
+	ServiceEventUpgradeStarted    = "upgrade_started"
+	ServiceEventUpgradeFinished   = "upgrade_finished"
+	ServiceEventBootstrapStarted  = "bootstrap_started"
+	ServiceEventBootstrapFinished = "bootstrap_finished"
+)
+
+type serviceEvent struct {
+	Time   time.Time `json:"time"`
+	Action string    `json:"action"`
+	Apps   []string  `json:"apps"`
+}
+
+// serviceDowntime is time between the event taking the app down and the event bringing it up, End is nil if the app was not brought up
+type serviceDowntime struct {
+	Start  time.Time  `json:"start"`
+	End    *time.Time `json:"end,omitempty"`
+	Action string     `json:"action"`
+	Apps   []string   `json:"apps"`
+}
+
+func isServiceDownEvent(action string) bool {
+	return action == ServiceEventStop || action == ServiceEventUpgradeStarted || action == ServiceEventBootstrapStarted
+}
+
+func getServiceHistoryPath() string {
+	return path.Join(cli.BBdir, serviceHistoryFile)
+}
+
+// recordServiceEvent appends event to the service history, failures are only logged
+func recordServiceEvent(action string, apps []base.BakeBuddyApp) {
+	event := serviceEvent{
+		Time:   time.Now().UTC(),
+		Action: action,
+		Apps:   lo.Map(apps, func(app base.BakeBuddyApp, _ int) string { return app.GetId() }),
+	}
+	line, err := json.Marshal(event)
+	if err != nil {
+		return
+	}
+	file, err := os.OpenFile(getServiceHistoryPath(), os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0644)
+	if err != nil {
+		log.Debug("Failed to record service event!", "error", err.Error())
+		return
+	}
+	defer file.Close()
+	file.Write(append(line, '\n'))
+}
+
+func loadServiceHistory() ([]serviceEvent, error) {
+	file, err := os.Open(getServiceHistoryPath())
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	events := make([]serviceEvent, 0)
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		var event serviceEvent
+		if err := json.Unmarshal(scanner.Bytes(), &event); err != nil {
+			continue
+		}
+		events = append(events, event)
+	}
+	return events, scanner.Err()
+}
+
+// getServiceDowntimes pairs down events with the following up events of the same app, overlapping the from-to range
+func getServiceDowntimes(events []serviceEvent, from time.Time, to time.Time) []serviceDowntime {
+	downtimes := make([]serviceDowntime, 0)
+	open := map[string]*serviceDowntime{}
+	for _, event := range events {
+		for _, appId := range event.Apps {
+			current, isDown := open[appId]
+			switch {
+			case isServiceDownEvent(event.Action):
+				// the app is already down, the first event took it down
+				if !isDown {
+					open[appId] = &serviceDowntime{Start: event.Time, Action: event.Action, Apps: []string{appId}}
+				}
+			case isDown:
+				end := event.Time
+				current.End = &end
+				downtimes = append(downtimes, *current)
+				delete(open, appId)
+			}
+		}
+	}
+	for _, current := range open {
+		downtimes = append(downtimes, *current)
+	}
+	slices.SortStableFunc(downtimes, func(a, b serviceDowntime) int {
+		if c := a.Start.Compare(b.Start); c != 0 {
+			return c
+		}
+		return strings.Compare(a.Apps[0], b.Apps[0])
+	})
+	return lo.Filter(downtimes, func(downtime serviceDowntime, _ int) bool {
+		return !downtime.Start.After(to) && (downtime.End == nil || !downtime.End.Before(from))
+	})
+}
+
+// findServiceDowntime returns downtime which covers the time
+func findServiceDowntime(downtimes []serviceDowntime, at time.Time) *serviceDowntime {
+	downtime, found := lo.Find(downtimes, func(downtime serviceDowntime) bool {
+		return !at.Before(downtime.Start) && (downtime.End == nil || !at.After(*downtime.End))
+	})
+	if !found {
+		return nil
+	}
+	return &downtime
+}
@@ -129,6 +129,7 @@ var setupLedgerCmd = &cobra.Command{
 				amiArgs = append(amiArgs, fmt.Sprintf("--alias=%s", keyAlias))
 				exitCode, err = apps.Node.Execute(amiArgs...)
 				util.AssertEE(err, "Failed to import key to node!", exitCode)
+				refreshDalAttesterProfiles()
 			}
 		}
 	},
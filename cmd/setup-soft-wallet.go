@@ -92,6 +92,7 @@ var setupSoftWalletCmd = &cobra.Command{
 			amiArgs = append(amiArgs, fmt.Sprintf("--alias=%s", keyAlias))
 			exitCode, err = apps.Node.Execute(amiArgs...)
 			util.AssertEE(err, "Failed to import key to node!", exitCode)
+			refreshDalAttesterProfiles()
 		}
 	},
 }
@@ -109,7 +109,7 @@ var setupTezsignCmd = &cobra.Command{
 			amiArgs = append(amiArgs, fmt.Sprintf("--alias=%s", keyAlias))
 			exitCode, err = apps.Node.Execute(amiArgs...)
 			util.AssertEE(err, "Failed to import key to node!", exitCode)
-
+			refreshDalAttesterProfiles()
 		}
 	},
 }
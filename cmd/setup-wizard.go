@@ -0,0 +1,463 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"slices"
+	"strings"
+
+	"github.com/hjson/hjson-go/v4"
+	"github.com/samber/lo"
+	"github.com/tez-capital/tezbake/ami"
+	"github.com/tez-capital/tezbake/apps"
+	"github.com/tez-capital/tezbake/apps/base"
+	"github.com/tez-capital/tezbake/cli"
+	"github.com/tez-capital/tezbake/constants"
+	"github.com/tez-capital/tezbake/util"
+	"go.alis.is/common/log"
+
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/spf13/cobra"
+)
+
+// networkConfigurationBaseURL is the default of --network-configuration-url, blob urls are converted to raw ones (see apps/base/common.go)
+const networkConfigurationBaseURL = "https://github.com/tez-capital/xtz.configs/blob/main"
+
+const NetworkConfigurationUrl = "network-configuration-url"
+
+var setupKeyKinds = []string{"ledger", "tezsign", "soft", "none"}
+
+// setupManifestApp describes app to setup and where it runs
+type setupManifestApp struct {
+	Id            string `json:"id"`
+	Remote        string `json:"remote,omitempty"`
+	RemoteAuth    string `json:"remote_auth,omitempty"`
+	RemoteElevate string `json:"remote_elevate,omitempty"`
+	// RemoteElevateSource is the elevation credentials source spec, see setup --node-remote-elevate-source
+	RemoteElevateSource string `json:"remote_elevate_source,omitempty"`
+}
+
+// setupManifest is generated by the setup wizard and can be passed to setup --manifest
+type setupManifest struct {
+	Network   string             `json:"network"`
+	Apps      []setupManifestApp `json:"apps"`
+	KeyKind   string             `json:"key_kind,omitempty"`
+	Bootstrap bool               `json:"bootstrap,omitempty"`
+}
+
+func (manifest *setupManifest) hasApp(id string) bool {
+	return slices.ContainsFunc(manifest.Apps, func(app setupManifestApp) bool { return app.Id == id })
+}
+
+func loadSetupManifest(manifestPath string) (*setupManifest, error) {
+	data, err := os.ReadFile(manifestPath)
+	if err != nil {
+		return nil, err
+	}
+	manifest := &setupManifest{}
+	if err := hjson.Unmarshal(data, manifest); err != nil {
+		return nil, err
+	}
+	for _, app := range manifest.Apps {
+		if !slices.ContainsFunc(apps.All, func(v base.BakeBuddyApp) bool { return v.GetId() == app.Id }) {
+			return nil, fmt.Errorf("unknown app '%s'", app.Id)
+		}
+		if _, err := ami.ParseRemoteElevationKind(app.RemoteElevate); err != nil {
+			return nil, err
+		}
+		if app.RemoteElevateSource != "" {
+			if _, err := ami.ParseElevationCredentialsSource(app.RemoteElevateSource); err != nil {
+				return nil, err
+			}
+		}
+	}
+	if manifest.KeyKind != "" && !slices.Contains(setupKeyKinds, manifest.KeyKind) {
+		return nil, fmt.Errorf("unknown key kind '%s'", manifest.KeyKind)
+	}
+	return manifest, nil
+}
+
+func saveSetupManifest(manifestPath string, manifest *setupManifest) error {
+	data, err := json.MarshalIndent(manifest, "", "\t")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(manifestPath, data, 0644)
+}
+
+// getNetworkConfiguration returns configuration url of the network, mainnet is the default configuration
+func getNetworkConfiguration(baseUrl string, network string) string {
+	if network == "" || network == "mainnet" {
+		return ""
+	}
+	return fmt.Sprintf("%s/%s.json", strings.TrimSuffix(baseUrl, "/"), network)
+}
+
+// applySetupManifest translates the manifest to setup flags, explicitly set flags take precedence
+func applySetupManifest(cmd *cobra.Command, manifest *setupManifest) {
+	set := func(flag string, value string) {
+		if value == "" || cmd.Flags().Changed(flag) {
+			return
+		}
+		util.AssertEE(cmd.Flags().Set(flag, value), fmt.Sprintf("Failed to apply manifest flag '%s'!", flag), constants.ExitInvalidArgs)
+	}
+
+	for _, app := range manifest.Apps {
+		set(app.Id, "true")
+		switch app.Id {
+		case apps.Node.GetId():
+			set(NodeRemote, app.Remote)
+			set(NodeRemoteAuth, app.RemoteAuth)
+			set(NodeRemoteElevate, app.RemoteElevate)
+			set(NodeRemoteElevateSource, app.RemoteElevateSource)
+		case apps.DalNode.GetId():
+			set(WithDal, "true")
+			set(DalRemote, app.Remote)
+			set(DalRemoteAuth, app.RemoteAuth)
+			set(DalRemoteElevate, app.RemoteElevate)
+			set(DalRemoteElevateSource, app.RemoteElevateSource)
+		}
+	}
+	configuration := getNetworkConfiguration(util.GetCommandStringFlagSD(cmd, NetworkConfigurationUrl, networkConfigurationBaseURL), manifest.Network)
+	set(fmt.Sprintf("%s-configuration", apps.Node.GetId()), configuration)
+	set(fmt.Sprintf("%s-configuration", apps.Signer.GetId()), configuration)
+}
+
+// runTezbake runs tezbake subcommand against the current instance
+func runTezbake(args ...string) {
+	executable, err := os.Executable()
+	util.AssertEE(err, "Failed to locate tezbake executable!", constants.ExitInternalError)
+	log.Info("Running:", "cmd", "tezbake "+strings.Join(args, " "))
+	proc := exec.Command(executable, append([]string{"--" + PATH_FLAG, cli.BBdir}, args...)...)
+	proc.Stdin, proc.Stdout, proc.Stderr = os.Stdin, os.Stdout, os.Stderr
+	if err := proc.Run(); err != nil {
+		exitCode := constants.ExitExternalError
+		if exitError, ok := err.(*exec.ExitError); ok {
+			exitCode = exitError.ExitCode()
+		}
+		util.AssertEE(err, fmt.Sprintf("Failed to run '%s'!", args[0]), exitCode)
+	}
+}
+
+// runSetupManifestPostSteps runs key setup and bootstrap chosen in the manifest
+func runSetupManifestPostSteps(manifest *setupManifest) {
+	if manifest.hasApp(apps.Signer.GetId()) {
+		switch manifest.KeyKind {
+		case "ledger":
+			runTezbake("setup-ledger", "--platform", "--import-key", "--authorize")
+		case "tezsign":
+			// platform setup and key import can not run in one step
+			runTezbake("setup-tezsign", "--platform", "--init")
+			runTezbake("setup-tezsign", "--import-key")
+		case "soft":
+			runTezbake("setup-soft-wallet")
+		}
+	}
+
+	if manifest.Bootstrap && manifest.hasApp(apps.Node.GetId()) {
+		networkName := lo.CoalesceOrEmpty(manifest.Network, "mainnet")
+		if slices.ContainsFunc(availableNetworks, func(n network) bool { return n.name == networkName }) {
+			runTezbake("bootstrap-node", buildSnapshotURL(networkName, "rolling"))
+		} else {
+			runTezbake("bootstrap-node")
+		}
+	}
+}
+
+func printSetupManifestSummary(manifest *setupManifest) {
+	fmt.Println(constants.StyleTitle.Render("📋 Setup Summary"))
+	fmt.Printf("  Network:   %s\n", lo.CoalesceOrEmpty(manifest.Network, "mainnet"))
+	for _, app := range manifest.Apps {
+		location := "local"
+		if app.Remote != "" {
+			location = fmt.Sprintf("remote %s, elevate %s", app.Remote, lo.CoalesceOrEmpty(app.RemoteElevate, "none"))
+			if app.RemoteElevateSource != "" {
+				location += " - " + app.RemoteElevateSource
+			}
+		}
+		fmt.Printf("  App:       %s (%s)\n", app.Id, location)
+	}
+	if manifest.hasApp(apps.Signer.GetId()) {
+		fmt.Printf("  Key:       %s\n", lo.CoalesceOrEmpty(manifest.KeyKind, "none"))
+	}
+	if manifest.hasApp(apps.Node.GetId()) {
+		fmt.Printf("  Bootstrap: %v\n", manifest.Bootstrap)
+	}
+}
+
+// Setup wizard states
+type setupWizardState int
+
+const (
+	wizardStateNetwork setupWizardState = iota
+	wizardStateApps
+	wizardStateLocation
+	wizardStateRemote
+	wizardStateRemoteAuth
+	wizardStateRemoteElevate
+	wizardStateKeyKind
+	wizardStateBootstrap
+	wizardStateDone
+	wizardStateCanceled
+)
+
+// remoteElevationOption pairs elevation kind with credentials source offered by the wizard
+type remoteElevationOption struct {
+	label   string
+	elevate ami.RemoteElevationKind
+	source  ami.CredentialsSourceKind
+}
+
+var remoteElevationOptions = []remoteElevationOption{
+	{label: "sudo - password stored in encrypted file", elevate: ami.REMOTE_ELEVATION_SUDO, source: ami.CREDENTIALS_SOURCE_FILE},
+	{label: fmt.Sprintf("sudo - password from environment (%s)", ami.DefaultElevationPasswordEnv), elevate: ami.REMOTE_ELEVATION_SUDO, source: ami.CREDENTIALS_SOURCE_ENV},
+	{label: "sudo - password from system keyring", elevate: ami.REMOTE_ELEVATION_SUDO, source: ami.CREDENTIALS_SOURCE_KEYRING},
+	{label: "sudo - without password (host prepared by 'tezbake remote provision')", elevate: ami.REMOTE_ELEVATION_SUDO, source: ami.CREDENTIALS_SOURCE_NOPASSWD},
+	{label: "none - remote user is root", elevate: ami.REMOTE_ELEVATION_NONE},
+}
+
+// setupWizardModel is the TUI model of setup --interactive
+type setupWizardModel struct {
+	state    setupWizardState
+	cursor   int
+	manifest setupManifest
+
+	networks []string
+	appIds   []string
+	selected map[string]bool
+	// remoteAppIndex points to the app in manifest.Apps asked for location
+	remoteAppIndex int
+	input          textinput.Model
+}
+
+func newSetupWizardModel() setupWizardModel {
+	input := textinput.New()
+	input.Prompt = "> "
+	return setupWizardModel{
+		state:    wizardStateNetwork,
+		networks: lo.Map(availableNetworks, func(n network, _ int) string { return n.name }),
+		appIds:   lo.Map(apps.All, func(app base.BakeBuddyApp, _ int) string { return app.GetId() }),
+		selected: lo.SliceToMap(apps.Implicit, func(app base.BakeBuddyApp) (string, bool) { return app.GetId(), true }),
+		input:    input,
+	}
+}
+
+func (m setupWizardModel) Init() tea.Cmd {
+	return nil
+}
+
+func (m setupWizardModel) getOptions() []string {
+	switch m.state {
+	case wizardStateNetwork:
+		return m.networks
+	case wizardStateApps:
+		return m.appIds
+	case wizardStateLocation:
+		return []string{"local", "remote (over ssh)"}
+	case wizardStateRemoteElevate:
+		return lo.Map(remoteElevationOptions, func(option remoteElevationOption, _ int) string { return option.label })
+	case wizardStateKeyKind:
+		return setupKeyKinds
+	case wizardStateBootstrap:
+		return []string{"yes - import rolling snapshot", "no"}
+	}
+	return nil
+}
+
+func (m setupWizardModel) isTextState() bool {
+	return m.state == wizardStateRemote || m.state == wizardStateRemoteAuth
+}
+
+func (m setupWizardModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	keyMsg, ok := msg.(tea.KeyMsg)
+	if !ok {
+		return m, nil
+	}
+	switch keyMsg.String() {
+	case "ctrl+c", "esc":
+		m.state = wizardStateCanceled
+		return m, tea.Quit
+	case "enter":
+		return m.next()
+	}
+
+	if m.isTextState() {
+		var cmd tea.Cmd
+		m.input, cmd = m.input.Update(msg)
+		return m, cmd
+	}
+
+	switch keyMsg.String() {
+	case "q":
+		m.state = wizardStateCanceled
+		return m, tea.Quit
+	case "up", "k":
+		if m.cursor > 0 {
+			m.cursor--
+		}
+	case "down", "j":
+		if m.cursor < len(m.getOptions())-1 {
+			m.cursor++
+		}
+	case " ":
+		if m.state == wizardStateApps {
+			appId := m.appIds[m.cursor]
+			m.selected[appId] = !m.selected[appId]
+		}
+	}
+	return m, nil
+}
+
+// nextRemoteCapableApp moves to the next selected app which can run remotely, or to key selection
+func (m setupWizardModel) nextRemoteCapableApp(from int) setupWizardModel {
+	m.cursor = 0
+	for i := from; i < len(m.manifest.Apps); i++ {
+		if id := m.manifest.Apps[i].Id; id == apps.Node.GetId() || id == apps.DalNode.GetId() {
+			m.remoteAppIndex = i
+			m.state = wizardStateLocation
+			return m
+		}
+	}
+	return m.afterApps()
+}
+
+func (m setupWizardModel) afterApps() setupWizardModel {
+	m.cursor = 0
+	switch {
+	case m.manifest.hasApp(apps.Signer.GetId()):
+		m.state = wizardStateKeyKind
+	case m.manifest.hasApp(apps.Node.GetId()):
+		m.state = wizardStateBootstrap
+	default:
+		m.state = wizardStateDone
+	}
+	return m
+}
+
+func (m setupWizardModel) promptText(state setupWizardState, placeholder string) setupWizardModel {
+	m.state = state
+	m.input.Reset()
+	m.input.Placeholder = placeholder
+	m.input.Focus()
+	return m
+}
+
+func (m setupWizardModel) next() (tea.Model, tea.Cmd) {
+	switch m.state {
+	case wizardStateNetwork:
+		m.manifest.Network = m.networks[m.cursor]
+		m.state = wizardStateApps
+		m.cursor = 0
+	case wizardStateApps:
+		m.manifest.Apps = lo.FilterMap(m.appIds, func(id string, _ int) (setupManifestApp, bool) {
+			return setupManifestApp{Id: id}, m.selected[id]
+		})
+		if len(m.manifest.Apps) == 0 {
+			return m, nil
+		}
+		m = m.nextRemoteCapableApp(0)
+	case wizardStateLocation:
+		if m.cursor == 1 {
+			m = m.promptText(wizardStateRemote, "username:<ssh key file>@address")
+			return m, textinput.Blink
+		}
+		m = m.nextRemoteCapableApp(m.remoteAppIndex + 1)
+	case wizardStateRemote:
+		value := strings.TrimSpace(m.input.Value())
+		if value == "" {
+			return m, nil
+		}
+		m.manifest.Apps[m.remoteAppIndex].Remote = value
+		m = m.promptText(wizardStateRemoteAuth, "pass|key:<path to key> (empty for pass)")
+		return m, textinput.Blink
+	case wizardStateRemoteAuth:
+		m.manifest.Apps[m.remoteAppIndex].RemoteAuth = strings.TrimSpace(m.input.Value())
+		m.input.Blur()
+		m.state = wizardStateRemoteElevate
+		m.cursor = 0
+	case wizardStateRemoteElevate:
+		option := remoteElevationOptions[m.cursor]
+		m.manifest.Apps[m.remoteAppIndex].RemoteElevate = string(option.elevate)
+		m.manifest.Apps[m.remoteAppIndex].RemoteElevateSource = string(option.source)
+		m = m.nextRemoteCapableApp(m.remoteAppIndex + 1)
+	case wizardStateKeyKind:
+		m.manifest.KeyKind = setupKeyKinds[m.cursor]
+		m.cursor = 0
+		m.state = wizardStateDone
+		if m.manifest.hasApp(apps.Node.GetId()) {
+			m.state = wizardStateBootstrap
+		}
+	case wizardStateBootstrap:
+		m.manifest.Bootstrap = m.cursor == 0
+		m.state = wizardStateDone
+	}
+	if m.state == wizardStateDone {
+		return m, tea.Quit
+	}
+	return m, nil
+}
+
+func (m setupWizardModel) View() string {
+	var s strings.Builder
+	help := "\n↑/↓ navigate • enter select • q quit"
+
+	switch m.state {
+	case wizardStateNetwork:
+		s.WriteString(constants.StyleTitle.Render("🌐 Select Network"))
+	case wizardStateApps:
+		s.WriteString(constants.StyleTitle.Render("🧩 Select Apps"))
+		help = "\n↑/↓ navigate • space toggle • enter continue • q quit"
+	case wizardStateLocation:
+		s.WriteString(constants.StyleTitle.Render(fmt.Sprintf("📍 Where should %s run?", m.manifest.Apps[m.remoteAppIndex].Id)))
+	case wizardStateRemote:
+		s.WriteString(constants.StyleTitle.Render(fmt.Sprintf("🔗 Remote of %s", m.manifest.Apps[m.remoteAppIndex].Id)))
+		help = "\nenter continue • esc quit"
+	case wizardStateRemoteAuth:
+		s.WriteString(constants.StyleTitle.Render(fmt.Sprintf("🔑 Remote authentication of %s", m.manifest.Apps[m.remoteAppIndex].Id)))
+		help = "\nenter continue • esc quit"
+	case wizardStateRemoteElevate:
+		s.WriteString(constants.StyleTitle.Render(fmt.Sprintf("🛡 Remote elevation of %s", m.manifest.Apps[m.remoteAppIndex].Id)))
+	case wizardStateKeyKind:
+		s.WriteString(constants.StyleTitle.Render("🔐 Select Baker Key Type"))
+	case wizardStateBootstrap:
+		s.WriteString(constants.StyleTitle.Render("🥯 Bootstrap Node From Snapshot?"))
+	default:
+		return ""
+	}
+	s.WriteString("\n\n")
+
+	if m.isTextState() {
+		s.WriteString(m.input.View() + "\n")
+	} else {
+		for i, option := range m.getOptions() {
+			cursor := "  "
+			style := constants.StyleNormal
+			if m.cursor == i {
+				cursor = "▸ "
+				style = constants.StyleSelected
+			}
+			if m.state == wizardStateApps {
+				option = fmt.Sprintf("[%s] %s", map[bool]string{true: "x", false: " "}[m.selected[option]], option)
+			}
+			s.WriteString(fmt.Sprintf("%s%s\n", cursor, style.Render(option)))
+		}
+	}
+
+	s.WriteString(constants.StyleHelp.Render(help))
+	return s.String()
+}
+
+// runSetupWizard runs the interactive setup wizard, returns nil if canceled
+func runSetupWizard() *setupManifest {
+	result, err := tea.NewProgram(newSetupWizardModel(), tea.WithInput(os.Stdin), tea.WithOutput(os.Stdout)).Run()
+	util.AssertEE(err, "Failed to run setup wizard!", constants.ExitInternalError)
+	finalModel, ok := result.(setupWizardModel)
+	util.AssertBE(ok, "Unexpected model type from setup wizard!", constants.ExitInternalError)
+	if finalModel.state != wizardStateDone {
+		return nil
+	}
+	return &finalModel.manifest
+}
@@ -20,12 +20,14 @@ import (
 )
 
 const (
-	NodeRemote        = "node-remote"
-	NodeRemoteAuth    = "node-remote-auth"
-	NodeRemoteElevate = "node-remote-elevate"
-	DalRemote         = "dal-remote"
-	DalRemoteAuth     = "dal-remote-auth"
-	DalRemoteElevate  = "dal-remote-elevate"
+	NodeRemote              = "node-remote"
+	NodeRemoteAuth          = "node-remote-auth"
+	NodeRemoteElevate       = "node-remote-elevate"
+	NodeRemoteElevateSource = "node-remote-elevate-source"
+	DalRemote               = "dal-remote"
+	DalRemoteAuth           = "dal-remote-auth"
+	DalRemoteElevate        = "dal-remote-elevate"
+	DalRemoteElevateSource  = "dal-remote-elevate-source"
 	// RemoteElevateUser   = "remote-elevate-user"
 	// RemoteUser          = "remote-user"
 	// RemotePath          = "remote-path"
@@ -44,12 +46,28 @@ const (
 	Force               = "force"
 	WithDal             = "with-dal"
 	DisablePostProcess  = "disable-post-process"
+	Interactive         = "interactive"
+	Manifest            = "manifest"
+	SaveManifest        = "save-manifest"
 )
 
+func getRemoteElevateSource(cmd *cobra.Command, flag string) *ami.ElevationCredentialsSource {
+	spec := util.GetCommandStringFlagS(cmd, flag)
+	if spec == "" {
+		return nil
+	}
+	source, err := ami.ParseElevationCredentialsSource(spec)
+	util.AssertEE(err, "Invalid elevation credentials source!", constants.ExitInvalidArgs)
+	return source
+}
+
 var setupCmd = &cobra.Command{
 	Use:   "setup",
 	Short: "Setups BB.",
-	Long:  "Installs and configures BB instance.",
+	Long: `Installs and configures BB instance.
+
+With --interactive the wizard guides through network, apps, remote locations, key type and bootstrap,
+the choices are saved to a manifest which can be reused with --manifest.`,
 	Run: func(cmd *cobra.Command, args []string) {
 		username := util.GetCommandStringFlag(cmd, User)
 		system.RequireElevatedUser("--user=" + username)
@@ -63,6 +81,28 @@ var setupCmd = &cobra.Command{
 			}
 		}
 
+		var manifest *setupManifest
+		if util.GetCommandBoolFlagS(cmd, Interactive) {
+			util.AssertBE(system.IsTty(), "Interactive setup requires a terminal!", constants.ExitInvalidArgs)
+			manifest = runSetupWizard()
+			if manifest == nil {
+				log.Info("Setup canceled.")
+				os.Exit(constants.ExitOperationCanceled)
+			}
+			printSetupManifestSummary(manifest)
+			util.ConfirmOrExit("Proceed with the setup?", true, "Failed to confirm setup!")
+			manifestPath := util.GetCommandStringFlagS(cmd, SaveManifest)
+			util.AssertEE(saveSetupManifest(manifestPath, manifest), "Failed to save setup manifest!", constants.ExitIOError)
+			log.Info("Setup manifest saved, reuse it with 'tezbake setup --manifest "+manifestPath+"'.", "path", manifestPath)
+		} else if manifestPath := util.GetCommandStringFlagS(cmd, Manifest); manifestPath != "" {
+			var err error
+			manifest, err = loadSetupManifest(manifestPath)
+			util.AssertEE(err, "Failed to load setup manifest!", constants.ExitInvalidArgs)
+		}
+		if manifest != nil {
+			applySetupManifest(cmd, manifest)
+		}
+
 		id := util.GetCommandStringFlagS(cmd, Id)
 		force := util.GetCommandBoolFlagS(cmd, Force)
 		disablePostProcess := util.GetCommandBoolFlagS(cmd, DisablePostProcess)
@@ -116,11 +156,13 @@ var setupCmd = &cobra.Command{
 				ctx.Remote = util.GetCommandStringFlagS(cmd, NodeRemote)
 				ctx.RemoteAuth = util.GetCommandStringFlagS(cmd, NodeRemoteAuth)
 				ctx.RemoteElevate = ami.RemoteElevationKind(util.GetCommandStringFlagS(cmd, NodeRemoteElevate))
+				ctx.RemoteElevateSource = getRemoteElevateSource(cmd, NodeRemoteElevateSource)
 				ctx.Dal = util.GetCommandBoolFlagS(cmd, WithDal) || apps.DalNode.IsInstalled()
 			case apps.DalNode.GetId():
 				ctx.Remote = util.GetCommandStringFlagS(cmd, DalRemote)
 				ctx.RemoteAuth = util.GetCommandStringFlagS(cmd, DalRemoteAuth)
 				ctx.RemoteElevate = ami.RemoteElevationKind(util.GetCommandStringFlagS(cmd, DalRemoteElevate))
+				ctx.RemoteElevateSource = getRemoteElevateSource(cmd, DalRemoteElevateSource)
 			}
 
 			if v.IsInstalled() && !force {
@@ -142,77 +184,14 @@ var setupCmd = &cobra.Command{
 			util.AssertEE(err, fmt.Sprintf("Failed to setup '%s'!", v.GetId()), exitCode)
 		}
 
-		if !disablePostProcess && apps.Node.IsInstalled() && !apps.DalNode.IsInstalled() {
-			nodeModel, err := apps.Node.GetActiveModel()
-			util.AssertEE(err, "Failed to load node definition!", constants.ExitActiveModelLoadFailed)
-
-			_, found := nodeModel["DAL_NODE"].(string)
-			if found {
-				isUserConfirmed := false
-				if system.IsTty() {
-					isUserConfirmed = util.Confirm("DAL_NODE is set in node definition but no dal node found. Do you want to remove it?", false, "Failed to confirm DAL_NODE removal!")
-				}
-				if isUserConfirmed {
-					log.Info("Removing dal node endpoint from node definition")
-					apps.Node.UpdateDalEndpoint("")
-				}
-			}
+		// post setup - link apps
+		if !disablePostProcess {
+			log.Info("Post setup - linking apps")
+			reconcileLinks(force)
 		}
 
-		// post setup - dal + node
-		if !disablePostProcess && apps.Node.IsInstalled() && apps.DalNode.IsInstalled() {
-			log.Info("Post setup - dal + node")
-
-			// link dal to node
-			nodeModel, err := apps.Node.GetActiveModel()
-			util.AssertEE(err, "Failed to load node active mode!", constants.ExitActiveModelLoadFailed)
-			dalModel, err := apps.DalNode.GetActiveModel()
-			util.AssertEE(err, "Failed to load dal active mode!", constants.ExitActiveModelLoadFailed)
-
-			nodeEndpoint, nodeEndpointFound := nodeModel["LOCAL_RPC_ADDR"].(string)
-			nodeDalEndpoint, _ := nodeModel["DAL_NODE"].(string)
-			dalEndpoint, dalEndpointFound := dalModel["LOCAL_RPC_ADDR"].(string)
-			dalNodeEndpoint, _ := dalModel["NODE_ENDPOINT"].(string)
-
-			util.AssertB(nodeEndpointFound, "Failed to get node endpoint!")
-			util.AssertB(dalEndpointFound, "Failed to get dal endpoint!")
-
-			// normalize
-			if !strings.HasPrefix(nodeEndpoint, "http") && !strings.HasPrefix(nodeEndpoint, "tcp") {
-				nodeEndpoint = "http://" + nodeEndpoint
-			}
-
-			if !strings.HasPrefix(dalEndpoint, "http") && !strings.HasPrefix(dalEndpoint, "tcp") {
-				dalEndpoint = "http://" + dalEndpoint
-			}
-			// check if dalNodeEndpoint equals nodeEndpoint with scheme
-
-			if dalNodeEndpoint != nodeEndpoint {
-				isUserConfirmed := dalNodeEndpoint == "" // TODO: || force update
-				if !isUserConfirmed && system.IsTty() {
-					isUserConfirmed = util.Confirm(fmt.Sprintf("DAL - node endpoint '%s' is different from actual node endpoint '%s'. Do you want to update the DAL - node endpoint to match the actual node endpoint?", dalNodeEndpoint, nodeEndpoint), false, "Failed to confirm DAL node endpoint update!")
-				}
-				if isUserConfirmed {
-					log.Info("Updating dal's node endpoint", "node_endpoint", nodeEndpoint)
-					util.AssertEE(apps.DalNode.UpdateNodeEndpoint(nodeEndpoint), "Failed to update dal node endpoint!", constants.ExitInternalError)
-					exitCode, err := apps.DalNode.Execute("setup", "--configure") // reconfigure to apply changes
-					util.AssertEE(err, "Failed to reconfigure dal node!", exitCode)
-					util.AssertBE(exitCode == 0, "Failed to setup dal node!", exitCode)
-				}
-			}
-			if nodeDalEndpoint != dalEndpoint {
-				isUserConfirmed := nodeDalEndpoint == "" // TODO: || force update
-				if !isUserConfirmed && system.IsTty() {
-					isUserConfirmed = util.Confirm(fmt.Sprintf("NODE - dal endpoint '%s' is different from actual dal endpoint '%s'. Do you want to update the NODE - dal endpoint to match the actual dal endpoint?", nodeDalEndpoint, dalEndpoint), false, "Failed to confirm node dal endpoint update!")
-				}
-				if isUserConfirmed {
-					log.Info("Updating node's dal endpoint", "dal_endpoint", dalEndpoint)
-					util.AssertEE(apps.Node.UpdateDalEndpoint(dalEndpoint), "Failed to update dal node endpoint!", constants.ExitInternalError)
-					exitCode, err := apps.Node.Execute("setup", "--configure") // reconfigure to apply changes
-					util.AssertEE(err, "Failed to reconfigure node!", exitCode)
-					util.AssertBE(exitCode == 0, "Failed to setup node!", exitCode)
-				}
-			}
+		if manifest != nil {
+			runSetupManifestPostSteps(manifest)
 		}
 
 		log.Info("Setup successful")
@@ -243,15 +222,22 @@ func init() {
 	setupCmd.Flags().String(NodeRemote, "", "username:<ssh key file>@address (experimental)")
 	setupCmd.Flags().String(NodeRemoteAuth, "", "pass|key:<path to key>  (experimental)")
 	setupCmd.Flags().String(NodeRemoteElevate, "", "only 'sudo' supported now (experimental)")
+	setupCmd.Flags().String(NodeRemoteElevateSource, "", "file|env[:VAR]|command:<cmd>|keyring[:service/account]|vault:<path>[#field]|nopasswd (experimental)")
 
 	setupCmd.Flags().Bool(WithDal, false, "Setup dal node. (experimental)")
 	setupCmd.Flags().String(DalRemote, "", "username:<ssh key file>@address (experimental)")
 	setupCmd.Flags().String(DalRemoteAuth, "", "pass|key:<path to key>  (experimental)")
 	setupCmd.Flags().String(DalRemoteElevate, "", "only 'sudo' supported now (experimental)")
+	setupCmd.Flags().String(DalRemoteElevateSource, "", "file|env[:VAR]|command:<cmd>|keyring[:service/account]|vault:<path>[#field]|nopasswd (experimental)")
 
 	setupCmd.Flags().Bool(RemoteReset, false, "Resets and reconfigures remote node locator. (experimental)")
 	setupCmd.Flags().Bool(DisablePostProcess, false, "Disables post process - app linking node <-> dal.")
 
+	setupCmd.Flags().Bool(Interactive, false, "Guides through the setup and generates reusable manifest.")
+	setupCmd.Flags().String(Manifest, "", "Setups BB from manifest generated by interactive setup.")
+	setupCmd.Flags().String(NetworkConfigurationUrl, networkConfigurationBaseURL, "Base url of network configurations (<url>/<network>.json) used by --interactive and --manifest.")
+	setupCmd.Flags().String(SaveManifest, "tezbake-setup.json", "Where to save manifest generated by interactive setup.")
+
 	setupCmd.Flags().String(Branch, "main", "Select package release branch you want to setup (addets node and signer app only).")
 	RootCmd.AddCommand(setupCmd)
 }
@@ -17,14 +17,22 @@ var startCmd = &cobra.Command{
 	Long:  "Starts services of BB instance.",
 	Run: func(cmd *cobra.Command, args []string) {
 		system.RequireElevatedUser()
+		if util.GetCommandBoolFlagS(cmd, WhenIdleFlag) {
+			// a stopped node has no duties to wait for
+			options := getWhenIdleOptions(cmd)
+			options.SkipIfNodeStopped = true
+			waitForIdleWindow(options)
+		}
 
-		for _, v := range GetAppsBySelectionCriteria(cmd, AppSelectionCriteria{
+		appsToStart := GetAppsBySelectionCriteria(cmd, AppSelectionCriteria{
 			InitialSelection:  InstalledApps,
 			FallbackSelection: ImplicitApps,
-		}) {
+		})
+		for _, v := range appsToStart {
 			exitCode, err := v.Start()
 			util.AssertEE(err, fmt.Sprintf("Failed to starts %s's services!", v.GetId()), exitCode)
 		}
+		recordServiceEvent(ServiceEventStart, appsToStart)
 
 		log.Info("Requested services started successfully")
 	},
@@ -34,5 +42,6 @@ func init() {
 	for _, v := range apps.All {
 		startCmd.Flags().Bool(v.GetId(), false, fmt.Sprintf("Starts %s's services.", v.GetId()))
 	}
+	addWhenIdleFlags(startCmd)
 	RootCmd.AddCommand(startCmd)
 }
@@ -17,11 +17,14 @@ var stopCmd = &cobra.Command{
 	Long:  "Stops services of BB instance.",
 	Run: func(cmd *cobra.Command, args []string) {
 		system.RequireElevatedUser()
+		waitForIdleWindowIfRequested(cmd)
 
-		for _, v := range GetAppsBySelectionCriteria(cmd, AppSelectionCriteria{
+		appsToStop := GetAppsBySelectionCriteria(cmd, AppSelectionCriteria{
 			InitialSelection:  InstalledApps,
 			FallbackSelection: ImplicitApps,
-		}) {
+		})
+		recordServiceEvent(ServiceEventStop, appsToStop)
+		for _, v := range appsToStop {
 			exitCode, err := v.Stop()
 			util.AssertEE(err, fmt.Sprintf("Failed to stop %s's services!", v.GetId()), exitCode)
 		}
@@ -34,5 +37,6 @@ func init() {
 	for _, v := range apps.All {
 		stopCmd.Flags().Bool(v.GetId(), false, fmt.Sprintf("Stops %s's services.", v.GetId()))
 	}
+	addWhenIdleFlags(stopCmd)
 	RootCmd.AddCommand(stopCmd)
 }
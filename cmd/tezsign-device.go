@@ -0,0 +1,274 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"regexp"
+	"sort"
+	"strconv"
+
+	"github.com/samber/lo"
+	"github.com/tez-capital/tezbake/apps"
+	"github.com/tez-capital/tezbake/apps/base"
+	"github.com/tez-capital/tezbake/apps/signer"
+	"github.com/tez-capital/tezbake/cli"
+	"github.com/tez-capital/tezbake/constants"
+	"github.com/tez-capital/tezbake/util"
+	"go.alis.is/common/log"
+
+	"github.com/jedib0t/go-pretty/v6/table"
+	"github.com/spf13/cobra"
+)
+
+const tezsignBackupSeedGuidance = `tezsign keys are generated on the device and the seed never leaves it through tezbake.
+
+To back up the seed:
+  1. Disconnect the device from the baker and connect it to an offline machine.
+  2. Follow the backup procedure of your tezsign device and write the seed down on paper (or metal).
+  3. Never type the seed into a networked machine, never photograph it, never store it in a password manager synced online.
+  4. Verify the backup by restoring it on a spare device and comparing addresses with 'tezbake tezsign-device list-keys'.
+  5. Store the backup separately from the device and from the password.
+
+If the device is lost, restore the seed on a new device, run 'tezbake setup-tezsign --import-key' and
+make sure high watermarks are not lower than the last signed level ('tezbake tezsign-device hwm inspect').`
+
+type tezsignKeyStatus struct {
+	Alias      string `json:"alias"`
+	Pkh        string `json:"pkh"`
+	DevicePath string `json:"device_path,omitempty"`
+	Connected  bool   `json:"connected"`
+	Firmware   string `json:"firmware,omitempty"`
+	Unlocked   bool   `json:"unlocked"`
+	Authorized bool   `json:"authorized"`
+	Status     string `json:"status"`
+}
+
+func getTezsignKeyStatuses(wallets map[string]base.AmiWalletInfo) []tezsignKeyStatus {
+	aliases := lo.Keys(lo.PickBy(wallets, func(_ string, wallet base.AmiWalletInfo) bool { return wallet.Kind == "tezsign" }))
+	sort.Strings(aliases)
+	return lo.Map(aliases, func(alias string, _ int) tezsignKeyStatus {
+		wallet := wallets[alias]
+		return tezsignKeyStatus{
+			Alias:      alias,
+			Pkh:        wallet.Pkh,
+			DevicePath: wallet.DevicePath,
+			Connected:  wallet.DevicePath != "",
+			Firmware:   wallet.Firmware,
+			Unlocked:   !wallet.Locked,
+			Authorized: wallet.Authorized,
+			Status:     getWalletStatus(wallet),
+		}
+	})
+}
+
+// e.g. "level: 1234, round: 0" or "level 1234 round 0"
+var tezsignHwmRegex = regexp.MustCompile(`(?i)level\W*(\d+)(?:\W+round\W*(\d+))?`)
+
+// parseTezsignHwm parses output of 'tezsign hwm <alias>', ledger formats are accepted as well
+func parseTezsignHwm(output string) ([]ledgerHwmValue, error) {
+	if values, err := parseLedgerHwm(output); err == nil {
+		return values, nil
+	}
+	result := make([]ledgerHwmValue, 0)
+	for _, match := range tezsignHwmRegex.FindAllStringSubmatch(output, -1) {
+		level, _ := strconv.ParseInt(match[1], 10, 64)
+		round, _ := strconv.ParseInt(lo.CoalesceOrEmpty(match[2], "0"), 10, 64)
+		result = append(result, ledgerHwmValue{Kind: "all", Level: level, Round: round})
+	}
+	if len(result) == 0 {
+		return nil, fmt.Errorf("unexpected high watermark output - %s", output)
+	}
+	return result, nil
+}
+
+// getTezsignHwm reads the current high watermark of the tezsign key
+func getTezsignHwm(alias string) ([]ledgerHwmValue, error) {
+	output, exitCode, err := apps.Signer.ExecuteGetOutput("tezsign", "hwm", alias)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get tezsign high watermark - %s", err.Error())
+	}
+	if exitCode != 0 {
+		return nil, fmt.Errorf("failed to get tezsign high watermark (exit code %d)", exitCode)
+	}
+	return parseTezsignHwm(output)
+}
+
+// checkTezsignHwm checks the new high watermark against the current one and node head,
+// current is nil if it could not be read, then any level below the head is treated as risk as well
+func checkTezsignHwm(current []ledgerHwmValue, headLevel int64, newLevel int64) (risks []string, warnings []string) {
+	risks, warnings = checkLedgerHwm(current, headLevel, newLevel)
+	if current != nil {
+		return risks, warnings
+	}
+	risks = append([]string{"current high watermark is not known, the device could sign levels it already signed (double signing)"}, risks...)
+	return append(risks, warnings...), nil
+}
+
+func requireTezsignSigner() {
+	util.AssertBE(apps.Signer.IsInstalled(), "Signer is not installed!", constants.ExitAppNotInstalled)
+}
+
+// executeTezsign runs tezsign command through the signer app and exits on failure
+func executeTezsign(errMsg string, args ...string) {
+	exitCode, err := apps.Signer.Execute(append([]string{"tezsign"}, args...)...)
+	util.AssertEE(err, errMsg, exitCode)
+	util.AssertBE(exitCode == 0, errMsg, exitCode)
+}
+
+var tezsignDeviceCmd = &cobra.Command{
+	Use:   "tezsign-device",
+	Short: "Manages tezsign devices.",
+	Long: `Manages tezsign devices used by the signer.
+
+Use 'tezbake tezsign' to pass args through to signer app - tezsign.`,
+}
+
+var tezsignStatusCmd = &cobra.Command{
+	Use:   "status",
+	Short: "Prints status of tezsign keys.",
+	Long:  "Prints whether the tezsign device of each tezsign key in the signer is connected, unlocked and its firmware.",
+	Args:  cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		requireTezsignSigner()
+		signerInfo, err := apps.Signer.GetInfoFromOptions(&signer.InfoCollectionOptions{Wallets: true})
+		util.AssertEE(err, "Failed to collect signer keys!", constants.ExitExternalError)
+		statuses := getTezsignKeyStatuses(signerInfo.Wallets)
+
+		if cli.JsonLogFormat {
+			output, err := json.Marshal(statuses)
+			util.AssertEE(err, "Failed to serialize tezsign status!", constants.ExitSerializationFailed)
+			fmt.Println(string(output))
+			return
+		}
+
+		statusTable := table.NewWriter()
+		statusTable.SetStyle(table.StyleLight)
+		statusTable.SetOutputMirror(os.Stdout)
+		statusTable.AppendHeader(table.Row{"Alias", "Address", "Device", "Firmware", "Unlocked", "Authorized", "Status"})
+		for _, status := range statuses {
+			statusTable.AppendRow(table.Row{status.Alias, status.Pkh, lo.CoalesceOrEmpty(status.DevicePath, "not connected"), status.Firmware, status.Unlocked, status.Authorized, status.Status})
+		}
+		if len(statuses) == 0 {
+			statusTable.AppendRow(table.Row{"N/A"})
+		}
+		statusTable.Render()
+	},
+}
+
+var tezsignListKeysCmd = &cobra.Command{
+	Use:   "list-keys",
+	Short: "Lists keys stored on the tezsign device.",
+	Long:  "Lists keys stored on the tezsign device, including keys not imported to the signer.",
+	Args:  cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		requireTezsignSigner()
+		executeTezsign("Failed to list tezsign keys!", "list")
+	},
+}
+
+var tezsignUnlockCmd = &cobra.Command{
+	Use:   "unlock [--password-command <command>]",
+	Short: "Unlocks tezsign keys.",
+	Long: `Unlocks keys on the tezsign device so the signer can sign with them.
+
+tezsign prompts for the password unless --password-command is set, in which case the first line
+of the command output is written to the tezsign password prompt.
+Remote signers prompt for the password on the remote host.`,
+	Args: cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		requireTezsignSigner()
+		passwordCommand := util.GetCommandStringFlagS(cmd, "password-command")
+		if passwordCommand == "" {
+			executeTezsign("Failed to unlock tezsign keys!", "unlock")
+			return
+		}
+		util.AssertBE(!apps.Signer.IsRemoteApp(), "--password-command is not supported for remote signer!", constants.ExitNotSupported)
+
+		password, err := util.ReadSecretFromCommand(passwordCommand)
+		util.AssertEE(err, "Failed to read tezsign password!", constants.ExitInvalidArgs)
+		// written to stdin of tezsign so the password does not appear in process list or environment
+		exitCode, err := apps.Signer.ExecuteWithInput([]byte(password+"\n"), "tezsign", "unlock")
+		util.AssertEE(err, "Failed to unlock tezsign keys!", exitCode)
+		log.Info("tezsign keys unlocked.")
+	},
+}
+
+var tezsignBackupSeedCmd = &cobra.Command{
+	Use:   "backup-seed",
+	Short: "Prints guidance for backing up tezsign seed.",
+	Long:  "Prints guidance for backing up tezsign seed.",
+	Args:  cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		fmt.Println(tezsignBackupSeedGuidance)
+	},
+}
+
+var tezsignHwmCmd = &cobra.Command{
+	Use:   "hwm",
+	Short: "Manages high watermarks of tezsign keys.",
+	Long:  "Inspects and resets high watermarks tezsign uses to prevent double signing.",
+}
+
+var tezsignHwmInspectCmd = &cobra.Command{
+	Use:   "inspect [<alias>]",
+	Short: "Prints high watermarks of tezsign keys.",
+	Long:  "Prints the last signed level and round of tezsign keys.",
+	Args:  cobra.MaximumNArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		requireTezsignSigner()
+		executeTezsign("Failed to inspect high watermarks!", append([]string{"hwm"}, args...)...)
+	},
+}
+
+var tezsignHwmResetCmd = &cobra.Command{
+	Use:   "reset <alias> --level <level> [--force] [--confirm]",
+	Short: "Resets high watermark of tezsign key.",
+	Long: `Resets high watermark of tezsign key to the level.
+
+Setting the high watermark below the last signed level allows the key to sign the same level again,
+which may result in double signing and slashing. Reset is refused if the level is below the current
+high watermark (double signing risk) or ahead of the node head (missed rights) unless --force is used.
+If the current high watermark can not be read, any level below the node head is refused as well.`,
+	Args: cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		requireTezsignSigner()
+		level, err := strconv.ParseInt(util.GetCommandStringFlagS(cmd, "level"), 10, 64)
+		util.AssertBE(err == nil && level >= 0, "Invalid level!", constants.ExitInvalidArgs)
+
+		var head nodeHeadHeader
+		if err := apps.Signer.RpcGet("/chains/main/blocks/head/header", &head); err != nil {
+			log.Warn("Failed to get node head!", "error", err.Error())
+		}
+		current, err := getTezsignHwm(args[0])
+		if err != nil {
+			log.Warn("Failed to read current high watermark!", "error", err.Error())
+		}
+		risks, warnings := checkTezsignHwm(current, head.Level, level)
+		for _, warning := range warnings {
+			log.Warn(warning)
+		}
+		for _, risk := range risks {
+			log.Error(risk)
+		}
+		util.AssertBE(len(risks) == 0 || util.GetCommandBoolFlagS(cmd, "force"), "Refusing to reset high watermark, use --force to override!", constants.ExitInvalidArgs)
+
+		if !util.GetCommandBoolFlagS(cmd, "confirm") {
+			util.ConfirmOrExit(fmt.Sprintf("Reset high watermark of '%s' to level %d?", args[0], level), len(risks) == 0, "Failed to confirm high watermark reset!")
+		}
+		executeTezsign("Failed to reset high watermark!", "hwm", args[0], "--set", strconv.FormatInt(level, 10))
+		log.Info("High watermark reset.", "alias", args[0], "level", level)
+	},
+}
+
+func init() {
+	tezsignUnlockCmd.Flags().String("password-command", "", "Command printing the tezsign password.")
+	tezsignHwmResetCmd.Flags().String("level", "", "Level to reset high watermark to.")
+	tezsignHwmResetCmd.Flags().Bool("force", false, "Resets high watermark despite double signing or missed rights risk.")
+	tezsignHwmResetCmd.Flags().Bool("confirm", false, "Skips confirmation.")
+	tezsignHwmResetCmd.MarkFlagRequired("level")
+
+	tezsignHwmCmd.AddCommand(tezsignHwmInspectCmd, tezsignHwmResetCmd)
+	tezsignDeviceCmd.AddCommand(tezsignStatusCmd, tezsignListKeysCmd, tezsignUnlockCmd, tezsignBackupSeedCmd, tezsignHwmCmd)
+	RootCmd.AddCommand(tezsignDeviceCmd)
+}
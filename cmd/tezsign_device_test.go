@@ -0,0 +1,47 @@
+package cmd
+
+import "testing"
+
+func TestParseTezsignHwm(t *testing.T) {
+	tests := []struct {
+		output   string
+		expected int64
+	}{
+		{output: "baker: level 1234, round 1", expected: 1234},
+		{output: "Level: 1234 Round: 0", expected: 1234},
+		{output: "- 1234 (round: 0) for block,", expected: 1234},
+	}
+	for _, tt := range tests {
+		values, err := parseTezsignHwm(tt.output)
+		if err != nil || len(values) == 0 || values[0].Level != tt.expected {
+			t.Errorf("%q: expected level %d, got %v (%v)", tt.output, tt.expected, values, err)
+		}
+	}
+	if _, err := parseTezsignHwm("no watermark"); err == nil {
+		t.Errorf("expected error for unexpected output")
+	}
+}
+
+func TestCheckTezsignHwm(t *testing.T) {
+	current := []ledgerHwmValue{{Kind: "all", Level: 100}}
+	tests := []struct {
+		current  []ledgerHwmValue
+		head     int64
+		level    int64
+		expected int
+	}{
+		{current: current, head: 105, level: 105, expected: 0},
+		{current: current, head: 105, level: 106, expected: 0},
+		// below head but above the current high watermark is only a warning
+		{current: current, head: 105, level: 102, expected: 0},
+		{current: current, head: 105, level: 90, expected: 1},
+		{current: current, head: 105, level: 200, expected: 1},
+		{current: nil, head: 105, level: 102, expected: 2},
+		{current: nil, head: 0, level: 100, expected: 2},
+	}
+	for _, tt := range tests {
+		if risks, _ := checkTezsignHwm(tt.current, tt.head, tt.level); len(risks) != tt.expected {
+			t.Errorf("current %v head %d level %d: expected %d risks, got %v", tt.current, tt.head, tt.level, tt.expected, risks)
+		}
+	}
+}
@@ -3,7 +3,9 @@ package cmd
 import (
 	"fmt"
 	"os"
+	"slices"
 	"strings"
+	"time"
 
 	"github.com/samber/lo"
 	"github.com/tez-capital/tezbake/apps"
@@ -15,11 +17,153 @@ import (
 	"github.com/spf13/cobra"
 )
 
+// getLocalNodeRpcUrl returns rpc url of the node reachable from this machine, empty if none
+func getLocalNodeRpcUrl() string {
+	var endpoint string
+	switch {
+	case apps.Node.IsInstalled() && !apps.Node.IsRemoteApp():
+		if nodeModel, err := apps.Node.GetActiveModel(); err == nil {
+			endpoint, _ = nodeModel["LOCAL_RPC_ADDR"].(string)
+		}
+	case apps.DalNode.IsInstalled() && !apps.DalNode.IsRemoteApp():
+		if dalModel, err := apps.DalNode.GetActiveModel(); err == nil {
+			endpoint, _ = dalModel["NODE_ENDPOINT"].(string)
+		}
+	}
+	if endpoint != "" && !strings.HasPrefix(endpoint, "http") {
+		endpoint = "http://" + endpoint
+	}
+	return endpoint
+}
+
+// addTzktFlags adds --tzkt-url and --no-tzkt, usage describes what TzKT is used for
+func addTzktFlags(cmd *cobra.Command, usage string, noTzktUsage string) {
+	cmd.Flags().String("tzkt-url", "", fmt.Sprintf("%s (defaults to %s or %s).", usage, util.TzktUrlEnv, constants.TzktConsensusKeyCheckingEndpoint))
+	cmd.Flags().Bool("no-tzkt", false, noTzktUsage)
+}
+
+// getTzktUrl returns TzKT url from flags, empty if TzKT is disabled
+func getTzktUrl(cmd *cobra.Command) string {
+	if util.GetCommandBoolFlagS(cmd, "no-tzkt") {
+		return ""
+	}
+	return util.GetCommandStringFlagSD(cmd, "tzkt-url", util.DefaultTzktUrl())
+}
+
+func addAttestationProfileResolverFlags(cmd *cobra.Command) {
+	cmd.Flags().String("rpc", "", "Node rpc used to resolve keys (defaults to local node).")
+	addTzktFlags(cmd, "TzKT api used as fallback to resolve keys", "Resolves keys through node rpc only.")
+}
+
+func getAttestationProfileResolver(cmd *cobra.Command) *util.AttestationProfileResolver {
+	resolver := &util.AttestationProfileResolver{
+		NodeRpcUrl: util.GetCommandStringFlagS(cmd, "rpc"),
+		TzktUrl:    getTzktUrl(cmd),
+	}
+	if resolver.NodeRpcUrl == "" {
+		resolver.NodeRpcUrl = getLocalNodeRpcUrl()
+	}
+	util.AssertBE(resolver.NodeRpcUrl != "" || resolver.TzktUrl != "", "No node rpc available to resolve keys, please provide --rpc!", constants.ExitInvalidArgs)
+	return resolver
+}
+
+// getNodeBakers returns keys of bakers configured on the node
+func getNodeBakers() ([]string, error) {
+	output, exitCode, err := apps.Node.ExecuteGetOutput("list-bakers")
+	if err != nil {
+		return nil, err
+	}
+	if exitCode != 0 {
+		return nil, fmt.Errorf("list-bakers failed with exit code %d", exitCode)
+	}
+	return lo.Compact(lo.Map(strings.Split(strings.TrimSpace(output), "\n"), func(key string, _ int) string {
+		return strings.TrimSpace(key)
+	})), nil
+}
+
+// resolveDalAttesterProfiles resolves keys to attester profiles, with autodetect bakers of the node are included
+func resolveDalAttesterProfiles(resolver *util.AttestationProfileResolver, keys []string, autodetect bool, force bool) ([]string, error) {
+	if autodetect {
+		foundKeys, err := getNodeBakers()
+		if err != nil {
+			return nil, fmt.Errorf("failed to get baker key hash - %s", err.Error())
+		}
+		log.Debug("Found node bakers", "keys", foundKeys)
+		keys = append(keys, foundKeys...)
+	}
+
+	profiles := make([]string, 0, len(keys))
+	for _, key := range keys {
+		profile := key
+		if !force {
+			var err error
+			profile, err = resolver.Resolve(key)
+			if err != nil {
+				return nil, err
+			}
+		}
+		profiles = append(profiles, profile)
+	}
+	profiles = lo.Uniq(profiles)
+	slices.Sort(profiles)
+	return profiles, nil
+}
+
+// syncDalAttesterProfiles writes profiles and reconfigures dal node only if they differ from the configured ones
+func syncDalAttesterProfiles(profiles []string) (bool, error) {
+	currentProfiles, err := apps.DalNode.GetAttesterProfiles()
+	if err != nil {
+		return false, fmt.Errorf("failed to load attester profiles - %s", err.Error())
+	}
+	added, removed := lo.Difference(profiles, currentProfiles)
+	if len(added) == 0 && len(removed) == 0 {
+		log.Info("Attester profiles are up to date.", "profiles", profiles)
+		return false, nil
+	}
+	log.Info("Attester profiles changed, updating dal node...", "added", added, "removed", removed)
+
+	if err := apps.DalNode.SetAttesterProfiles(profiles); err != nil {
+		return false, fmt.Errorf("failed to set attester profiles - %s", err.Error())
+	}
+	exitCode, err := apps.DalNode.Execute("setup", "--configure") // reconfigure to apply changes
+	if err != nil || exitCode != 0 {
+		return false, fmt.Errorf("failed to setup dal node (exit code %d)", exitCode)
+	}
+	log.Info("Attester profiles updated successfully.", "profiles", profiles)
+	return true, nil
+}
+
+// refreshDalAttesterProfiles syncs dal attester profiles with node bakers if both dal and node are installed,
+// failures are only reported as the hook should not break the calling command
+func refreshDalAttesterProfiles() {
+	if !apps.DalNode.IsInstalled() || !apps.Node.IsInstalled() {
+		return
+	}
+	resolver := &util.AttestationProfileResolver{NodeRpcUrl: getLocalNodeRpcUrl(), TzktUrl: util.DefaultTzktUrl()}
+	profiles, err := resolveDalAttesterProfiles(resolver, []string{}, true, false)
+	if err == nil {
+		_, err = syncDalAttesterProfiles(profiles)
+	}
+	if err != nil {
+		log.Warn("Failed to refresh DAL attester profiles, please run 'tezbake update-dal-profiles --auto'.", "error", err.Error())
+	}
+}
+
+// minDalProfilesWatchInterval keeps watch mode from hammering the node and TzKT
+const minDalProfilesWatchInterval = time.Minute
+
 var updateDalProfilesCmd = &cobra.Command{
-	Use:   "update-dal-profiles (<profile>... | --auto) [--force]",
+	Use:   "update-dal-profiles (<profile>... | --auto) [--force] [--watch [--interval <duration>]]",
 	Short: "Updates dal profiles.",
-	Long:  "Updates dal profiles.",
-	Args:  cobra.ArbitraryArgs,
+	Long: `Updates dal profiles.
+
+Consensus and companion keys are resolved to their delegates through the node rpc,
+TzKT is used as a fallback unless --no-tzkt is set.
+The dal node is reconfigured only if the resolved profiles differ from the configured ones.
+
+With --watch profiles are resolved periodically, so the dal node follows baker keys
+added to the node or rotated consensus keys.`,
+	Args: cobra.ArbitraryArgs,
 	Run: func(cmd *cobra.Command, args []string) {
 		util.AssertBE(apps.DalNode.IsInstalled(), "DAL node is not installed!", constants.ExitAppNotInstalled)
 		util.AssertBE(apps.Node.IsInstalled(), "Octez node is not installed!", constants.ExitAppNotInstalled)
@@ -28,11 +172,14 @@ var updateDalProfilesCmd = &cobra.Command{
 
 		autodetect := util.GetCommandBoolFlag(cmd, "auto")
 		force := util.GetCommandBoolFlag(cmd, "force")
+		watch := util.GetCommandBoolFlag(cmd, "watch")
+		interval, _ := cmd.Flags().GetDuration("interval")
+		util.AssertBE(!watch || interval >= minDalProfilesWatchInterval, fmt.Sprintf("Interval has to be at least %s!", minDalProfilesWatchInterval), constants.ExitInvalidArgs)
 
 		args = util.RemoveCmdFlags(cmd, args)
 
 		if len(args) == 0 && !autodetect {
-			isUserConfirmed := util.Confirm("No keys provided. Do you want to autodetect?", true, "Failed to confirm autodetect option!")
+			isUserConfirmed := watch || util.Confirm("No keys provided. Do you want to autodetect?", true, "Failed to confirm autodetect option!")
 			if !isUserConfirmed {
 				fmt.Println("No keys provided. Exiting.")
 				os.Exit(constants.ExitOperationCanceled)
@@ -40,45 +187,35 @@ var updateDalProfilesCmd = &cobra.Command{
 			autodetect = true
 		}
 
-		keys := args
-
-		if autodetect {
-			output, exitCode, err := apps.Node.ExecuteGetOutput("list-bakers")
-			util.AssertEE(err, "Failed to get baker key hash!", exitCode)
-			util.AssertB(exitCode == 0, "Failed to get baker key hash!")
-
-			foundKeys := strings.Split(strings.TrimSpace(string(output)), "\n")
-			log.Info("Importing keys to dal node...", "keys", keys)
-
-			keys = append(keys, foundKeys...)
+		resolver := getAttestationProfileResolver(cmd)
+		if !watch {
+			profiles, err := resolveDalAttesterProfiles(resolver, args, autodetect, force)
+			util.AssertEE(err, "Failed to resolve attestation profile!", constants.ExitInternalError)
+			_, err = syncDalAttesterProfiles(profiles)
+			util.AssertEE(err, "Failed to update attester profiles!", constants.ExitAppConfigurationLoadFailed)
+			return
 		}
 
-		profiles := make([]string, 0, len(keys))
-		for _, key := range keys {
-			profile := key
-			if !force {
-				var err error
-				profile, err = util.ResolveAttestationProfile(key)
-				util.AssertEE(err, "Failed to resolve attestation profile!", constants.ExitInternalError)
+		log.Info("Watching attester profiles...", "interval", interval)
+		for {
+			profiles, err := resolveDalAttesterProfiles(resolver, args, autodetect, force)
+			if err == nil {
+				_, err = syncDalAttesterProfiles(profiles)
 			}
-			profiles = append(profiles, profile)
+			if err != nil {
+				log.Warn("Failed to sync attester profiles, retrying later.", "error", err.Error())
+			}
+			time.Sleep(interval)
 		}
-
-		log.Info("Attester profiles resolved successfully, updating dal node...", "profiles", profiles)
-
-		err := apps.DalNode.SetAttesterProfiles(lo.Uniq(profiles))
-		util.AssertEE(err, "Failed to set attester profiles!", constants.ExitAppConfigurationLoadFailed)
-
-		exitCode, err := apps.DalNode.Execute("setup", "--configure") // reconfigure to apply changes
-		util.AssertEE(err, "Failed to setup dal node!", exitCode)
-		util.AssertBE(exitCode == 0, "Failed to setup dal node!", exitCode)
-		log.Info("Attester profiles updated successfully. ", "profiles", profiles)
 	},
 }
 
 func init() {
 	updateDalProfilesCmd.Flags().Bool("auto", false, "Autodetect attester profiles")
 	updateDalProfilesCmd.Flags().Bool("force", false, "Force update attester profiles")
+	updateDalProfilesCmd.Flags().Bool("watch", false, "Keeps attester profiles in sync with node bakers")
+	updateDalProfilesCmd.Flags().Duration("interval", 10*time.Minute, "How often to check attester profiles in watch mode")
+	addAttestationProfileResolverFlags(updateDalProfilesCmd)
 
 	RootCmd.AddCommand(updateDalProfilesCmd)
 }
@@ -38,10 +38,13 @@ var upgradeCmd = &cobra.Command{
 			FallbackSelection: ImplicitApps,
 		})
 
+		waitForIdleWindowIfRequested(cmd)
+		recordServiceEvent(ServiceEventUpgradeStarted, appsToUpgrade)
 		for _, v := range appsToUpgrade {
 			exitCode, err := v.Upgrade(upgradeContext)
 			util.AssertEE(err, fmt.Sprintf("Failed to upgrade '%s'!", v.GetId()), exitCode)
 		}
+		recordServiceEvent(ServiceEventUpgradeFinished, appsToUpgrade)
 		log.Info("Upgrade successful.")
 	},
 }
@@ -53,5 +56,6 @@ func init() {
 
 	upgradeCmd.Flags().BoolP(UpgradeStorage, "s", false, "Upgrade storage during the upgrade.")
 	upgradeCmd.Flags().Bool(SkipAmiSetup, false, "Skip ami upgrade")
+	addWhenIdleFlags(upgradeCmd)
 	RootCmd.AddCommand(upgradeCmd)
 }
@@ -0,0 +1,190 @@
+package cmd
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/samber/lo"
+	"github.com/tez-capital/tezbake/apps"
+	"github.com/tez-capital/tezbake/apps/node"
+	"github.com/tez-capital/tezbake/constants"
+	"github.com/tez-capital/tezbake/util"
+	"go.alis.is/common/log"
+
+	"github.com/spf13/cobra"
+)
+
+const (
+	WhenIdleFlag           = "when-idle"
+	MinIdleFlag            = "min-idle"
+	MaxWaitFlag            = "max-wait"
+	IgnoreAttestationsFlag = "ignore-attestations"
+	WhenIdleForceFlag      = "when-idle-force"
+)
+
+// idle window is re-checked at least this often, estimated block times drift
+const idleWindowPollInterval = time.Minute
+
+type whenIdleOptions struct {
+	MinIdle time.Duration
+	MaxWait time.Duration
+	// IgnoreAttestations waits only for a window without baking duties, bakers with
+	// regular stake attest almost every level so windows without attestations are rare
+	IgnoreAttestations bool
+	// Force proceeds without waiting if rights can not be checked
+	Force bool
+	// SkipIfNodeStopped proceeds without waiting if the node is not running, there are no duties to protect
+	SkipIfNodeStopped bool
+}
+
+func addWhenIdleFlags(cmd *cobra.Command) {
+	cmd.Flags().Bool(WhenIdleFlag, false, "Waits for a window without baking and attestation duties before proceeding.")
+	cmd.Flags().Duration(MinIdleFlag, 2*time.Minute, "Minimal length of the window without duties required by --when-idle.")
+	cmd.Flags().Duration(MaxWaitFlag, 2*time.Hour, "How long --when-idle waits for the window before failing.")
+	cmd.Flags().Bool(IgnoreAttestationsFlag, false, "Waits only for a window without baking duties, attestations in the window are missed.")
+	cmd.Flags().Bool(WhenIdleForceFlag, false, "Proceeds without waiting if rights can not be checked (node or its rpc not available, keys without rights).")
+}
+
+// getIdleDuties returns rights with duties which have to be avoided
+func getIdleDuties(rights *node.Rights, ignoreAttestations bool) *node.Rights {
+	if !ignoreAttestations {
+		return rights
+	}
+	result := *rights
+	result.Duties = lo.Filter(rights.Duties, func(duty node.Duty, _ int) bool { return duty.Kind != node.DutyKindAttestation })
+	return &result
+}
+
+// findNextIdleWindow returns the first window without duties which leaves at least minIdle from now
+func findNextIdleWindow(rights *node.Rights, minIdle time.Duration, now time.Time) *maintenanceWindow {
+	window, found := lo.Find(getIdleWindows(rights), func(window maintenanceWindow) bool {
+		return window.End.Sub(lo.Latest(window.Start, now)) >= minIdle
+	})
+	if !found {
+		return nil
+	}
+	return &window
+}
+
+func reportIdleWindowRisk(rights *node.Rights, window *maintenanceWindow, now time.Time) {
+	nextDuty, found := lo.Find(rights.Duties, func(duty node.Duty) bool { return duty.Level > window.ToLevel })
+	if !found {
+		log.Info("No known duties after the window.", "window_end_level", window.ToLevel, "available", window.End.Sub(now).Round(time.Second).String())
+		return
+	}
+	log.Warn("Operation has to finish before the next duty, otherwise the duty is missed.",
+		"available", window.End.Sub(now).Round(time.Second).String(), "level", nextDuty.Level, "kind", nextDuty.Kind, "delegate", nextDuty.Delegate)
+}
+
+// skipIdleWaitOrFail proceeds without waiting if forced, fails otherwise
+func skipIdleWaitOrFail(options whenIdleOptions, msg string, err error) {
+	args := []any{}
+	if err != nil {
+		args = append(args, "error", err.Error())
+	}
+	util.AssertBE(options.Force, fmt.Sprintf("%s Use --%s to proceed without waiting.", msg, WhenIdleForceFlag), constants.ExitCheckFailed)
+	log.Warn(msg+" Proceeding without waiting for idle window.", args...)
+}
+
+// waitForIdleWindow blocks until baker keys of the node have no duty for at least minIdle,
+// fails immediately if rights can not be checked unless forced
+func waitForIdleWindow(options whenIdleOptions) {
+	if !apps.Node.IsInstalled() {
+		skipIdleWaitOrFail(options, "Node is not installed, rights can not be checked!", nil)
+		return
+	}
+	if options.SkipIfNodeStopped {
+		if isRunning, err := apps.Node.IsAnyServiceStatus("running"); err == nil && !isRunning {
+			log.Info("Node is not running, there are no duties to wait for.")
+			return
+		}
+	}
+	delegates, err := getRightsDelegates()
+	if err != nil {
+		skipIdleWaitOrFail(options, "Failed to get baker keys!", err)
+		return
+	}
+	if len(delegates) == 0 {
+		log.Info("No baker keys found, there are no duties to wait for.")
+		return
+	}
+
+	rpc, err := apps.Node.NewRpcClient(30 * time.Second)
+	if err != nil {
+		skipIdleWaitOrFail(options, "Failed to connect to node rpc!", err)
+		return
+	}
+	defer rpc.Close()
+	delegates = resolveRightsDelegates(rpc, delegates)
+
+	deadline := time.Now().Add(options.MaxWait)
+	for {
+		now := time.Now()
+		wait := idleWindowPollInterval
+		rights, err := collectIdleRights(rpc, delegates)
+		if err != nil {
+			skipIdleWaitOrFail(options, "Failed to collect rights!", err)
+			return
+		}
+		rights = getIdleDuties(rights, options.IgnoreAttestations)
+		window := findNextIdleWindow(rights, options.MinIdle, now)
+		if window != nil && window.FromLevel <= rights.HeadLevel+1 {
+			log.Info("Duty-free window reached.", "from_level", window.FromLevel, "to_level", window.ToLevel, "head_level", rights.HeadLevel, "ignore_attestations", options.IgnoreAttestations)
+			reportIdleWindowRisk(rights, window, now)
+			if options.IgnoreAttestations {
+				log.Warn("Attestation duties are ignored, attestations during the operation are missed.")
+			}
+			return
+		}
+		if window != nil {
+			wait = min(max(window.Start.Sub(now), time.Second), idleWindowPollInterval)
+			log.Info("Waiting for duty-free window...", "from_level", window.FromLevel, "to_level", window.ToLevel, "starts_in", window.Start.Sub(now).Round(time.Second).String())
+		} else {
+			log.Info("No duty-free window found in collected rights, waiting...", "min_idle", options.MinIdle.String())
+		}
+		util.AssertBE(now.Add(wait).Before(deadline), fmt.Sprintf("No duty-free window of %s found within %s!", options.MinIdle, options.MaxWait), constants.ExitCheckFailed)
+		time.Sleep(wait)
+	}
+}
+
+// checkIdleRights fails if rights are incomplete or a delegate the node bakes for has no rights,
+// a window computed without its duties could overlap them
+func checkIdleRights(rights *node.Rights, delegates []string) error {
+	if len(rights.Errors) > 0 {
+		return fmt.Errorf("rights are incomplete - %s", strings.Join(rights.Errors, "; "))
+	}
+	withoutRights := lo.Filter(delegates, func(delegate string, _ int) bool {
+		return !lo.ContainsBy(rights.Duties, func(duty node.Duty) bool { return duty.Delegate == delegate })
+	})
+	if len(withoutRights) > 0 {
+		return fmt.Errorf("no rights found for %s", strings.Join(withoutRights, ", "))
+	}
+	return nil
+}
+
+func collectIdleRights(rpc *node.RpcClient, delegates []string) (*node.Rights, error) {
+	rights, err := rpc.GetRights(delegates, 2)
+	if err != nil {
+		return nil, err
+	}
+	return rights, checkIdleRights(rights, delegates)
+}
+
+func getWhenIdleOptions(cmd *cobra.Command) whenIdleOptions {
+	options := whenIdleOptions{
+		IgnoreAttestations: util.GetCommandBoolFlagS(cmd, IgnoreAttestationsFlag),
+		Force:              util.GetCommandBoolFlagS(cmd, WhenIdleForceFlag),
+	}
+	options.MinIdle, _ = cmd.Flags().GetDuration(MinIdleFlag)
+	options.MaxWait, _ = cmd.Flags().GetDuration(MaxWaitFlag)
+	return options
+}
+
+// waitForIdleWindowIfRequested waits for idle window if --when-idle is set
+func waitForIdleWindowIfRequested(cmd *cobra.Command) {
+	if !util.GetCommandBoolFlagS(cmd, WhenIdleFlag) {
+		return
+	}
+	waitForIdleWindow(getWhenIdleOptions(cmd))
+}
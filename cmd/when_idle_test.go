@@ -0,0 +1,87 @@
+package cmd
+
+import (
+	"testing"
+	"time"
+
+	"github.com/tez-capital/tezbake/apps/node"
+)
+
+func TestFindNextIdleWindow(t *testing.T) {
+	headTime := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	rights := &node.Rights{
+		HeadLevel:         100,
+		HeadTime:          headTime,
+		LastLevel:         130,
+		MinimalBlockDelay: 10,
+		Duties: []node.Duty{
+			{Level: 103},
+			{Level: 106},
+			{Level: 120},
+		},
+	}
+
+	// current window 101-102 is long enough
+	window := findNextIdleWindow(rights, 20*time.Second, headTime)
+	if window == nil || window.FromLevel != 101 {
+		t.Fatalf("expected current window, got %+v", window)
+	}
+
+	// current window is too short, first long enough window is 107-119
+	window = findNextIdleWindow(rights, time.Minute, headTime)
+	if window == nil || window.FromLevel != 107 || window.ToLevel != 119 {
+		t.Fatalf("expected window 107-119, got %+v", window)
+	}
+
+	// remaining part of the window counts from now
+	window = findNextIdleWindow(rights, time.Minute, headTime.Add(140*time.Second))
+	if window == nil || window.FromLevel != 121 {
+		t.Fatalf("expected window from 121, got %+v", window)
+	}
+
+	if window := findNextIdleWindow(rights, time.Hour, headTime); window != nil {
+		t.Errorf("expected no window, got %+v", window)
+	}
+}
+
+func TestGetIdleDutiesIgnoringAttestations(t *testing.T) {
+	headTime := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	rights := &node.Rights{
+		HeadLevel:         100,
+		HeadTime:          headTime,
+		LastLevel:         130,
+		MinimalBlockDelay: 10,
+		Duties: []node.Duty{
+			{Level: 101, Kind: node.DutyKindAttestation},
+			{Level: 102, Kind: node.DutyKindAttestation},
+			{Level: 110, Kind: node.DutyKindBaking},
+			{Level: 111, Kind: node.DutyKindAttestation},
+		},
+	}
+
+	if window := findNextIdleWindow(getIdleDuties(rights, false), time.Minute, headTime); window == nil || window.FromLevel != 103 {
+		t.Fatalf("expected window from 103, got %+v", window)
+	}
+	window := findNextIdleWindow(getIdleDuties(rights, true), time.Minute, headTime)
+	if window == nil || window.FromLevel != 101 || window.ToLevel != 109 {
+		t.Fatalf("expected window 101-109 ignoring attestations, got %+v", window)
+	}
+	if len(rights.Duties) != 4 {
+		t.Errorf("original rights should not be modified")
+	}
+}
+
+func TestCheckIdleRights(t *testing.T) {
+	rights := &node.Rights{Duties: []node.Duty{{Delegate: "tz1a", Level: 101}}}
+	if err := checkIdleRights(rights, []string{"tz1a"}); err != nil {
+		t.Errorf("expected no error, got %v", err)
+	}
+	// consensus key not resolved to its delegate has no rights
+	if err := checkIdleRights(rights, []string{"tz1a", "tz4consensus"}); err == nil {
+		t.Errorf("expected error for key without rights")
+	}
+	incomplete := &node.Rights{Duties: rights.Duties, Errors: []string{"failed to get attestation rights"}}
+	if err := checkIdleRights(incomplete, []string{"tz1a"}); err == nil {
+		t.Errorf("expected error for incomplete rights")
+	}
+}
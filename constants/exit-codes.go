@@ -22,4 +22,5 @@ const (
 	ExitNotSupported      = 153
 	ExitOperationCanceled = 154
 	ExitAppNotInstalled   = 155
+	ExitCheckFailed       = 156
 )
@@ -54,4 +54,10 @@ var (
 	// StyleHint is used for hints like [Y/n]
 	StyleHint = lipgloss.NewStyle().
 			Foreground(ColorTextDim)
+
+	// StyleHighlight is used for values which changed since the last refresh
+	StyleHighlight = lipgloss.NewStyle().
+			Foreground(ColorCursor).
+			Bold(true).
+			Reverse(true)
 )
@@ -0,0 +1,137 @@
+package fleet
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/hjson/hjson-go/v4"
+	"github.com/tez-capital/tezbake/ami"
+	"github.com/tez-capital/tezbake/constants"
+	"github.com/tez-capital/tezbake/system"
+	"github.com/tez-capital/tezbake/util"
+)
+
+const DefaultInventoryFile = "fleet.hjson"
+
+// Instance is a tezbake instance reachable over ssh
+type Instance struct {
+	Alias string `json:"alias"`
+	// Host is [user@]host[:port]
+	Host string `json:"host"`
+	// User used when host does not specify one
+	User string `json:"user"`
+	// Path of the tezbake instance on the host
+	Path string `json:"path"`
+	// Key is the path to the private key used to log in
+	Key               string                          `json:"key"`
+	Elevate           ami.RemoteElevationKind         `json:"elevate"`
+	CredentialsSource *ami.ElevationCredentialsSource `json:"credentials_source"`
+}
+
+// Inventory is the content of fleet.hjson, e.g.:
+//
+//	{
+//		defaults: { user: "bb", key: "~/.ssh/id_ed25519", elevate: "sudo", credentials_source: { kind: "nopasswd" } }
+//		instances: [
+//			{ alias: "baker-1", host: "10.0.0.1" }
+//			{ alias: "baker-2", host: "bb@10.0.0.2:2222", path: "/bake-buddy-2" }
+//		]
+//	}
+type Inventory struct {
+	Defaults  Instance   `json:"defaults"`
+	Instances []Instance `json:"instances"`
+}
+
+func expandHome(path string) string {
+	if rest, ok := strings.CutPrefix(path, "~/"); ok {
+		if home, err := os.UserHomeDir(); err == nil {
+			return filepath.Join(home, rest)
+		}
+	}
+	return path
+}
+
+func LoadInventory(path string) (*Inventory, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read fleet inventory - %s", err.Error())
+	}
+	var inventory Inventory
+	if err := hjson.Unmarshal(content, &inventory); err != nil {
+		return nil, fmt.Errorf("failed to parse fleet inventory - %s", err.Error())
+	}
+	if err := inventory.normalize(); err != nil {
+		return nil, err
+	}
+	return &inventory, nil
+}
+
+func (inventory *Inventory) normalize() error {
+	defaults := inventory.Defaults
+	util.AssignIfEmpty(&defaults.User, constants.DefaultRemoteUser)
+	util.AssignIfEmpty(&defaults.Path, constants.DefaultBBDirectory)
+	util.AssignIfEmpty(&defaults.Key, "~/.ssh/id_ed25519")
+
+	if len(inventory.Instances) == 0 {
+		return errors.New("fleet inventory does not contain any instances")
+	}
+	aliases := make([]string, 0, len(inventory.Instances))
+	for i := range inventory.Instances {
+		instance := &inventory.Instances[i]
+		if instance.Host == "" {
+			return fmt.Errorf("instance #%d does not specify host", i+1)
+		}
+		util.AssignStructFieldsIfEmpty(instance, &defaults)
+		util.AssignIfEmpty(&instance.Alias, instance.Host)
+		instance.Key = expandHome(instance.Key)
+		if instance.Elevate != ami.REMOTE_ELEVATION_NONE && instance.CredentialsSource.IsFile() {
+			return fmt.Errorf("instance '%s' requires credentials_source to elevate", instance.Alias)
+		}
+		if slices.Contains(aliases, instance.Alias) {
+			return fmt.Errorf("duplicate instance alias '%s'", instance.Alias)
+		}
+		aliases = append(aliases, instance.Alias)
+	}
+	return nil
+}
+
+// Select returns instances matching the aliases, all instances if none provided
+func (inventory *Inventory) Select(aliases []string) ([]Instance, error) {
+	if len(aliases) == 0 {
+		return inventory.Instances, nil
+	}
+	result := make([]Instance, 0, len(aliases))
+	for _, alias := range aliases {
+		index := slices.IndexFunc(inventory.Instances, func(instance Instance) bool { return instance.Alias == alias })
+		if index < 0 {
+			return nil, fmt.Errorf("instance '%s' not found in fleet inventory", alias)
+		}
+		result = append(result, inventory.Instances[index])
+	}
+	return result, nil
+}
+
+// ToRemoteConfiguration creates locator used to open tezbake remote session to the instance
+func (instance *Instance) ToRemoteConfiguration() *ami.RemoteConfiguration {
+	host := instance.Host
+	if !strings.Contains(host, "@") {
+		host = instance.User + "@" + host
+	}
+	connectionDetails := system.GetRemoteConnectionDetails(host)
+	return &ami.RemoteConfiguration{
+		// fleet instances do not store credentials on disk, the directory only keys the credentials cache
+		ElevationCredentialsDirectory: "fleet:" + instance.Alias,
+		App:                           instance.Alias,
+		Host:                          connectionDetails.Host,
+		Port:                          connectionDetails.Port,
+		Username:                      connectionDetails.Username,
+		InstancePath:                  instance.Path,
+		Elevate:                       instance.Elevate,
+		CredentialsSource:             instance.CredentialsSource,
+		PrivateKey:                    instance.Key,
+	}
+}
@@ -0,0 +1,82 @@
+package fleet
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/tez-capital/tezbake/ami"
+	"github.com/tez-capital/tezbake/constants"
+)
+
+func writeInventory(t *testing.T, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), DefaultInventoryFile)
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestLoadInventory(t *testing.T) {
+	path := writeInventory(t, `{
+		defaults: { key: "/keys/fleet", elevate: "sudo", credentials_source: { kind: "nopasswd" } }
+		instances: [
+			{ alias: "baker-1", host: "10.0.0.1" }
+			{ host: "root@10.0.0.2:2222", path: "/bake-buddy-2", key: "/keys/other" }
+		]
+	}`)
+
+	inventory, err := LoadInventory(path)
+	if err != nil {
+		t.Fatalf("failed to load inventory: %v", err)
+	}
+	if len(inventory.Instances) != 2 {
+		t.Fatalf("expected 2 instances, got %d", len(inventory.Instances))
+	}
+
+	first := inventory.Instances[0].ToRemoteConfiguration()
+	if first.Username != constants.DefaultRemoteUser || first.Host != "10.0.0.1" || first.Port != "22" || first.InstancePath != constants.DefaultBBDirectory || first.PrivateKey != "/keys/fleet" {
+		t.Errorf("unexpected defaults applied: %+v", first)
+	}
+	if first.Elevate != ami.REMOTE_ELEVATION_SUDO || first.CredentialsSource == nil || first.CredentialsSource.Kind != ami.CREDENTIALS_SOURCE_NOPASSWD {
+		t.Errorf("unexpected elevation: %+v", first)
+	}
+
+	second := inventory.Instances[1]
+	if second.Alias != "root@10.0.0.2:2222" {
+		t.Errorf("expected host as alias, got %s", second.Alias)
+	}
+	secondConfig := second.ToRemoteConfiguration()
+	if secondConfig.Username != "root" || secondConfig.Port != "2222" || secondConfig.InstancePath != "/bake-buddy-2" || secondConfig.PrivateKey != "/keys/other" {
+		t.Errorf("unexpected instance configuration: %+v", secondConfig)
+	}
+
+	selected, err := inventory.Select([]string{"baker-1"})
+	if err != nil || len(selected) != 1 || selected[0].Alias != "baker-1" {
+		t.Errorf("unexpected selection: %+v (%v)", selected, err)
+	}
+	if _, err := inventory.Select([]string{"missing"}); err == nil {
+		t.Error("expected error for unknown alias")
+	}
+}
+
+func TestLoadInventoryInvalid(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+	}{
+		{name: "No instances", content: `{ instances: [] }`},
+		{name: "Missing host", content: `{ instances: [ { alias: "a" } ] }`},
+		{name: "Duplicate alias", content: `{ instances: [ { alias: "a", host: "h1" }, { alias: "a", host: "h2" } ] }`},
+		{name: "Elevation without source", content: `{ instances: [ { host: "h1", elevate: "sudo" } ] }`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := LoadInventory(writeInventory(t, tt.content)); err == nil {
+				t.Error("expected error")
+			}
+		})
+	}
+}
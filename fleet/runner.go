@@ -0,0 +1,207 @@
+package fleet
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/tez-capital/tezbake/ami"
+	"github.com/tez-capital/tezbake/system"
+	"go.alis.is/common/log"
+)
+
+type Status string
+
+const (
+	StatusOk      Status = "ok"
+	StatusFailed  Status = "failed"
+	StatusSkipped Status = "skipped"
+)
+
+const bootstrapPollInterval = 15 * time.Second
+
+type Result struct {
+	Alias    string `json:"alias"`
+	Host     string `json:"host"`
+	Status   Status `json:"status"`
+	ExitCode int    `json:"exit_code"`
+	Error    string `json:"error,omitempty"`
+	// Data holds parsed json output of the command
+	Data any `json:"data,omitempty"`
+	// Output holds raw output if it is not json
+	Output   string `json:"output,omitempty"`
+	Duration string `json:"duration"`
+}
+
+func newResult(instance *Instance) *Result {
+	return &Result{
+		Alias:  instance.Alias,
+		Host:   instance.Host,
+		Status: StatusFailed,
+	}
+}
+
+func (result *Result) fail(err error) *Result {
+	result.Status = StatusFailed
+	result.Error = err.Error()
+	return result
+}
+
+// lastLine returns the last non empty line, json output is printed after log lines
+func lastLine(output string) string {
+	lines := strings.Split(strings.TrimSpace(output), "\n")
+	return strings.TrimSpace(lines[len(lines)-1])
+}
+
+func (result *Result) fill(commandResult *system.SshCommandResult) {
+	result.ExitCode = commandResult.ExitCode
+	output := strings.TrimSpace(string(commandResult.Stdout))
+	var data any
+	if output != "" && json.Unmarshal([]byte(lastLine(output)), &data) == nil {
+		result.Data = data
+	} else {
+		result.Output = output
+	}
+
+	if commandResult.Error != nil || commandResult.ExitCode != 0 {
+		message := strings.TrimSpace(string(commandResult.Stderr))
+		if message == "" && commandResult.Error != nil {
+			message = commandResult.Error.Error()
+		}
+		result.fail(fmt.Errorf("exit code %d - %s", commandResult.ExitCode, message))
+		return
+	}
+	result.Status = StatusOk
+}
+
+func openSession(instance *Instance) (*ami.TezbakeRemoteSession, error) {
+	session, err := instance.ToRemoteConfiguration().OpenAppRemoteSession()
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect - %s", err.Error())
+	}
+	return session, nil
+}
+
+func executeOn(instance *Instance, args []string) *Result {
+	start := time.Now()
+	result := newResult(instance)
+	defer func() { result.Duration = time.Since(start).Round(time.Second).String() }()
+
+	session, err := openSession(instance)
+	if err != nil {
+		return result.fail(err)
+	}
+	defer session.Close()
+
+	log.Debug("executing on fleet instance", "alias", instance.Alias, "args", args)
+	result.fill(session.ExecuteTezbake(args...))
+	return result
+}
+
+// PrepareCredentials resolves elevation credentials of all instances upfront,
+// so password prompts do not interleave once commands run in parallel
+func PrepareCredentials(instances []Instance) error {
+	for _, instance := range instances {
+		if _, err := instance.ToRemoteConfiguration().GetElevationCredentials(); err != nil {
+			return fmt.Errorf("failed to get elevation credentials for '%s' - %s", instance.Alias, err.Error())
+		}
+	}
+	return nil
+}
+
+// Execute runs tezbake with args on all instances, at most concurrency at once.
+// Results are in the order of instances.
+func Execute(instances []Instance, concurrency int, args []string) []*Result {
+	if concurrency <= 0 {
+		concurrency = 1
+	}
+	results := make([]*Result, len(instances))
+	semaphore := make(chan struct{}, concurrency)
+	var wg sync.WaitGroup
+	for i := range instances {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			semaphore <- struct{}{}
+			defer func() { <-semaphore }()
+
+			results[i] = executeOn(&instances[i], args)
+			log.Info("Fleet instance finished.", "alias", instances[i].Alias, "status", results[i].Status)
+		}(i)
+	}
+	wg.Wait()
+	return results
+}
+
+func isNodeBootstrapped(data any) (bool, error) {
+	info, ok := data.(map[string]any)
+	if !ok {
+		return false, errors.New("unexpected info format")
+	}
+	node, ok := info["node"].(map[string]any)
+	if !ok {
+		// instance without node, nothing to wait for
+		return true, nil
+	}
+	bootstrapped, _ := node["bootstrapped"].(bool)
+	return bootstrapped, nil
+}
+
+// WaitForBootstrapped polls node info until it reports bootstrapped or timeout elapses
+func WaitForBootstrapped(instance *Instance, timeout time.Duration) error {
+	session, err := openSession(instance)
+	if err != nil {
+		return err
+	}
+	defer session.Close()
+
+	deadline := time.Now().Add(timeout)
+	for {
+		result := newResult(instance)
+		result.fill(session.ExecuteTezbake("--output-format=json", "--log-level=error", "info", "--node"))
+		if result.Status == StatusOk {
+			bootstrapped, err := isNodeBootstrapped(result.Data)
+			if err != nil {
+				return err
+			}
+			if bootstrapped {
+				return nil
+			}
+		}
+		if time.Now().After(deadline) {
+			return fmt.Errorf("node not bootstrapped within %s", timeout)
+		}
+		log.Info("Waiting for node to bootstrap...", "alias", instance.Alias)
+		time.Sleep(bootstrapPollInterval)
+	}
+}
+
+// RollingUpgrade upgrades instances one by one and waits for each node to bootstrap
+// before moving to the next one. Remaining instances are skipped after the first failure.
+func RollingUpgrade(instances []Instance, args []string, bootstrapTimeout time.Duration) []*Result {
+	results := make([]*Result, len(instances))
+	failed := false
+	for i := range instances {
+		instance := &instances[i]
+		if failed {
+			results[i] = newResult(instance)
+			results[i].Status = StatusSkipped
+			results[i].Error = "skipped after previous failure"
+			continue
+		}
+
+		log.Info("Upgrading fleet instance...", "alias", instance.Alias)
+		results[i] = executeOn(instance, append([]string{"upgrade"}, args...))
+		if results[i].Status == StatusOk {
+			if err := WaitForBootstrapped(instance, bootstrapTimeout); err != nil {
+				results[i].fail(err)
+			}
+		}
+		failed = results[i].Status != StatusOk
+		log.Info("Fleet instance finished.", "alias", instance.Alias, "status", results[i].Status)
+	}
+	return results
+}
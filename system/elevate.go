@@ -36,12 +36,18 @@ func RequireElevatedUser(injectArgs ...string) {
 	elevationKind := os.Getenv("ELEVATION_KIND")
 	// elevationUser := os.Getenv("ELEVATION_USER")
 	elevationPass := os.Getenv("ELEVATION_PASSWORD")
+	// restricted elevation is used on hosts prepared by remote provision
+	elevationRestricted := os.Getenv("ELEVATION_RESTRICTED") == "true"
 	switch elevationKind {
 	case "sudo":
 		// test with exit 0
 		testArgs := make([]string, 0)
 		testArgs = append(testArgs, "-S", "-E", "--")
 		testArgs = append(testArgs, "sh", "-c", "exit 0")
+		if elevationRestricted {
+			// provisioned sudoers allows only tezbake and does not allow to preserve environment
+			testArgs = []string{"-S", "--", os.Args[0], "--version"}
+		}
 		testProc := exec.Command("sudo", testArgs...)
 		testSuccess := false
 		done := make(chan error, 1)
@@ -65,7 +71,11 @@ func RequireElevatedUser(injectArgs ...string) {
 		util.AssertBE(testSuccess, "Sudo access test failed!", constants.ExitElevationRequired)
 
 		sudoArgs := make([]string, 0)
-		sudoArgs = append(sudoArgs, "-S", "-E", "--")
+		sudoArgs = append(sudoArgs, "-S")
+		if !elevationRestricted {
+			sudoArgs = append(sudoArgs, "-E")
+		}
+		sudoArgs = append(sudoArgs, "--")
 		sudoArgs = append(sudoArgs, os.Args...)
 		sudoArgs = append(sudoArgs, injectArgs...)
 		sudoProc := exec.Command("sudo", sudoArgs...)
@@ -161,6 +161,40 @@ func RunSshCommand(client *ssh.Client, cmd string, env *map[string]string) *SshC
 	}
 }
 
+// RunSshCommandWithInput runs the command with input passed to its stdin
+func RunSshCommandWithInput(client *ssh.Client, cmd string, env *map[string]string, input []byte) *SshCommandResult {
+	var stdout, stderr bytes.Buffer
+	session, err := client.NewSession()
+	if err != nil {
+		return &SshCommandResult{
+			Error:    err,
+			ExitCode: -1,
+		}
+	}
+	defer session.Close()
+	session.Stdin = bytes.NewReader(input)
+	session.Stdout = &stdout
+	session.Stderr = &stderr
+
+	cmd = buildUpEnv(env) + cmd
+
+	exitCode := 0
+	err = session.Run(cmd)
+	if err != nil {
+		exitCode = -1
+		if exitErr, ok := err.(*ssh.ExitError); ok {
+			exitCode = exitErr.ExitStatus()
+		}
+	}
+
+	return &SshCommandResult{
+		Stdout:   stdout.Bytes(),
+		Stderr:   stderr.Bytes(),
+		Error:    err,
+		ExitCode: exitCode,
+	}
+}
+
 func RunPipedSshCommand(client *ssh.Client, cmd string, env *map[string]string) *SshCommandResult {
 	session, err := client.NewSession()
 	if err != nil {
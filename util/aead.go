@@ -0,0 +1,182 @@
+package util
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+
+	"golang.org/x/crypto/argon2"
+)
+
+const (
+	EncryptedDataVersion = 1
+
+	EncryptedDataCipherAESGCM        = "aes-256-gcm"
+	EncryptedDataKdfArgon2id         = "argon2id"
+	encryptedDataSaltSize            = 16
+	encryptedDataKeySize             = 32
+	minimumArgon2Memory       uint32 = 8 * 1024
+	// parameters come from the unauthenticated file header, upper bounds keep tampered files from exhausting resources
+	maximumArgon2Memory  uint32 = 1024 * 1024
+	maximumArgon2Time    uint32 = 16
+	maximumArgon2Threads uint8  = 255
+)
+
+var (
+	ErrDecryptionFailed         = errors.New("failed to decrypt data - wrong password or tampered data")
+	ErrUnsupportedEncryptedData = errors.New("unsupported encrypted data format")
+)
+
+type Argon2Params struct {
+	Time    uint32 `json:"time"`
+	Memory  uint32 `json:"memory"`
+	Threads uint8  `json:"threads"`
+}
+
+// DefaultArgon2Params returns argon2id parameters used for newly encrypted data.
+// Defaults can be tuned through TEZBAKE_ARGON2_TIME, TEZBAKE_ARGON2_MEMORY (KiB) and TEZBAKE_ARGON2_THREADS.
+func DefaultArgon2Params() Argon2Params {
+	params := Argon2Params{
+		Time:    3,
+		Memory:  64 * 1024,
+		Threads: 4,
+	}
+	if value, err := strconv.ParseUint(os.Getenv("TEZBAKE_ARGON2_TIME"), 10, 32); err == nil && value > 0 && uint32(value) <= maximumArgon2Time {
+		params.Time = uint32(value)
+	}
+	if value, err := strconv.ParseUint(os.Getenv("TEZBAKE_ARGON2_MEMORY"), 10, 32); err == nil && uint32(value) >= minimumArgon2Memory && uint32(value) <= maximumArgon2Memory {
+		params.Memory = uint32(value)
+	}
+	if value, err := strconv.ParseUint(os.Getenv("TEZBAKE_ARGON2_THREADS"), 10, 8); err == nil && value > 0 {
+		params.Threads = uint8(value)
+	}
+	return params
+}
+
+func (params Argon2Params) validate() error {
+	if params.Time == 0 || params.Threads == 0 || params.Memory < minimumArgon2Memory ||
+		params.Time > maximumArgon2Time || params.Memory > maximumArgon2Memory || params.Threads > maximumArgon2Threads {
+		return fmt.Errorf("%w - invalid argon2 parameters (time: %d, memory: %d, threads: %d)", ErrUnsupportedEncryptedData, params.Time, params.Memory, params.Threads)
+	}
+	return nil
+}
+
+// EncryptedDataHeader describes how the payload was encrypted. The whole header
+// is authenticated as additional data, so tampering with it is detected on decryption.
+type EncryptedDataHeader struct {
+	Version   int          `json:"version"`
+	Cipher    string       `json:"cipher"`
+	Kdf       string       `json:"kdf"`
+	KdfParams Argon2Params `json:"kdf_params"`
+	Salt      []byte       `json:"salt"`
+}
+
+type EncryptedData struct {
+	EncryptedDataHeader
+	Nonce []byte `json:"nonce"`
+	Data  []byte `json:"data"`
+}
+
+func (header *EncryptedDataHeader) additionalData() ([]byte, error) {
+	return json.Marshal(header)
+}
+
+func (header *EncryptedDataHeader) newAEAD(password string) (cipher.AEAD, error) {
+	if header.Version != EncryptedDataVersion || header.Cipher != EncryptedDataCipherAESGCM || header.Kdf != EncryptedDataKdfArgon2id {
+		return nil, ErrUnsupportedEncryptedData
+	}
+	if err := header.KdfParams.validate(); err != nil {
+		return nil, err
+	}
+	if len(header.Salt) < encryptedDataSaltSize {
+		return nil, errors.New("salt is too short")
+	}
+
+	key := argon2.IDKey([]byte(password), header.Salt, header.KdfParams.Time, header.KdfParams.Memory, header.KdfParams.Threads, encryptedDataKeySize)
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+// EncryptWithPassword encrypts data with AES-256-GCM using key derived from password by argon2id.
+// The result is a self-describing JSON document.
+func EncryptWithPassword(password string, data []byte, params Argon2Params) ([]byte, error) {
+	salt := make([]byte, encryptedDataSaltSize)
+	if _, err := rand.Read(salt); err != nil {
+		return nil, err
+	}
+
+	encrypted := EncryptedData{
+		EncryptedDataHeader: EncryptedDataHeader{
+			Version:   EncryptedDataVersion,
+			Cipher:    EncryptedDataCipherAESGCM,
+			Kdf:       EncryptedDataKdfArgon2id,
+			KdfParams: params,
+			Salt:      salt,
+		},
+	}
+	aead, err := encrypted.newAEAD(password)
+	if err != nil {
+		return nil, err
+	}
+	additionalData, err := encrypted.additionalData()
+	if err != nil {
+		return nil, err
+	}
+
+	encrypted.Nonce = make([]byte, aead.NonceSize())
+	if _, err := rand.Read(encrypted.Nonce); err != nil {
+		return nil, err
+	}
+	encrypted.Data = aead.Seal(nil, encrypted.Nonce, data, additionalData)
+
+	return json.MarshalIndent(encrypted, "", "\t")
+}
+
+// IsEncryptedData checks whether data are in the versioned encrypted format.
+func IsEncryptedData(data []byte) bool {
+	var encrypted EncryptedData
+	return json.Unmarshal(data, &encrypted) == nil && encrypted.Version > 0
+}
+
+// DecryptWithPassword decrypts data produced by EncryptWithPassword.
+// Data in the legacy AES-CBC format are decrypted too, in that case isLegacy is set
+// so the caller can migrate them.
+func DecryptWithPassword(password string, data []byte) (decrypted []byte, isLegacy bool, err error) {
+	if !IsEncryptedData(data) {
+		decrypted, err = DecryptLegacy(password, data)
+		if err != nil {
+			return nil, true, ErrDecryptionFailed
+		}
+		return decrypted, true, nil
+	}
+
+	var encrypted EncryptedData
+	if err := json.Unmarshal(data, &encrypted); err != nil {
+		return nil, false, err
+	}
+	aead, err := encrypted.newAEAD(password)
+	if err != nil {
+		return nil, false, err
+	}
+	if len(encrypted.Nonce) != aead.NonceSize() {
+		return nil, false, errors.New("invalid nonce size")
+	}
+	additionalData, err := encrypted.additionalData()
+	if err != nil {
+		return nil, false, err
+	}
+
+	decrypted, err = aead.Open(nil, encrypted.Nonce, encrypted.Data, additionalData)
+	if err != nil {
+		return nil, false, ErrDecryptionFailed
+	}
+	return decrypted, false, nil
+}
@@ -0,0 +1,157 @@
+package util
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/json"
+	"errors"
+	"testing"
+)
+
+var testArgon2Params = Argon2Params{Time: 1, Memory: minimumArgon2Memory, Threads: 1}
+
+// encryptLegacy produces data in the format written by tezbake before the versioned format.
+func encryptLegacy(t *testing.T, password string, data []byte) []byte {
+	t.Helper()
+	salt := make([]byte, LegacySaltSize)
+	if _, err := rand.Read(salt); err != nil {
+		t.Fatal(err)
+	}
+	block, err := aes.NewCipher(PrepareAESKey(password, salt))
+	if err != nil {
+		t.Fatal(err)
+	}
+	plaintext := append([]byte(AES_DATA_PREFIX), data...)
+	padding := aes.BlockSize - len(plaintext)%aes.BlockSize
+	plaintext = append(plaintext, bytes.Repeat([]byte{byte(padding)}, padding)...)
+
+	ciphertext := make([]byte, aes.BlockSize+len(plaintext))
+	if _, err := rand.Read(ciphertext[:aes.BlockSize]); err != nil {
+		t.Fatal(err)
+	}
+	cipher.NewCBCEncrypter(block, ciphertext[:aes.BlockSize]).CryptBlocks(ciphertext[aes.BlockSize:], plaintext)
+	return append(ciphertext, salt...)
+}
+
+func TestEncryptDecryptWithPassword(t *testing.T) {
+	data := []byte(`{"kind":"sudo","user":"","password":"secret"}`)
+
+	encrypted, err := EncryptWithPassword("password", data, testArgon2Params)
+	if err != nil {
+		t.Fatalf("failed to encrypt: %v", err)
+	}
+	if !IsEncryptedData(encrypted) {
+		t.Fatal("expected versioned encrypted data")
+	}
+
+	decrypted, isLegacy, err := DecryptWithPassword("password", encrypted)
+	if err != nil {
+		t.Fatalf("failed to decrypt: %v", err)
+	}
+	if isLegacy {
+		t.Error("expected non legacy data")
+	}
+	if !bytes.Equal(decrypted, data) {
+		t.Errorf("expected %s, got %s", data, decrypted)
+	}
+}
+
+func TestDecryptWithPasswordFailures(t *testing.T) {
+	data := []byte("secret")
+	encrypted, err := EncryptWithPassword("password", data, testArgon2Params)
+	if err != nil {
+		t.Fatalf("failed to encrypt: %v", err)
+	}
+
+	tamper := func(modify func(*EncryptedData)) []byte {
+		var envelope EncryptedData
+		if err := json.Unmarshal(encrypted, &envelope); err != nil {
+			t.Fatal(err)
+		}
+		modify(&envelope)
+		result, err := json.Marshal(envelope)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return result
+	}
+
+	tests := []struct {
+		name     string
+		password string
+		data     []byte
+		expected error
+	}{
+		{
+			name:     "Wrong password",
+			password: "wrong",
+			data:     encrypted,
+			expected: ErrDecryptionFailed,
+		},
+		{
+			name:     "Tampered ciphertext",
+			password: "password",
+			data:     tamper(func(e *EncryptedData) { e.Data[0] ^= 0xff }),
+			expected: ErrDecryptionFailed,
+		},
+		{
+			name:     "Tampered kdf params",
+			password: "password",
+			data:     tamper(func(e *EncryptedData) { e.KdfParams.Time++ }),
+			expected: ErrDecryptionFailed,
+		},
+		{
+			name:     "Excessive kdf memory",
+			password: "password",
+			data:     tamper(func(e *EncryptedData) { e.KdfParams.Memory = maximumArgon2Memory + 1 }),
+			expected: ErrUnsupportedEncryptedData,
+		},
+		{
+			name:     "Excessive kdf time",
+			password: "password",
+			data:     tamper(func(e *EncryptedData) { e.KdfParams.Time = maximumArgon2Time + 1 }),
+			expected: ErrUnsupportedEncryptedData,
+		},
+		{
+			name:     "Unsupported version",
+			password: "password",
+			data:     tamper(func(e *EncryptedData) { e.Version = EncryptedDataVersion + 1 }),
+			expected: ErrUnsupportedEncryptedData,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, _, err := DecryptWithPassword(tt.password, tt.data)
+			if !errors.Is(err, tt.expected) {
+				t.Errorf("expected %v, got %v", tt.expected, err)
+			}
+		})
+	}
+}
+
+func TestDecryptWithPasswordLegacy(t *testing.T) {
+	data := []byte(`{"kind":"su","user":"root","password":"secret"}`)
+	legacy := encryptLegacy(t, "password", data)
+
+	if IsEncryptedData(legacy) {
+		t.Fatal("legacy data detected as versioned format")
+	}
+
+	if _, _, err := DecryptWithPassword("wrong", legacy); !errors.Is(err, ErrDecryptionFailed) {
+		t.Errorf("expected %v, got %v", ErrDecryptionFailed, err)
+	}
+
+	decrypted, isLegacy, err := DecryptWithPassword("password", legacy)
+	if err != nil {
+		t.Fatalf("failed to decrypt legacy data: %v", err)
+	}
+	if !isLegacy {
+		t.Error("expected legacy data")
+	}
+	if !bytes.Equal(decrypted, data) {
+		t.Errorf("expected %s, got %s", data, decrypted)
+	}
+}
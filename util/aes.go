@@ -1,19 +1,22 @@
 package util
 
 import (
-	"bytes"
 	"crypto/aes"
 	"crypto/cipher"
-	"crypto/rand"
 	"errors"
-	"io"
 	"strings"
 
 	"golang.org/x/crypto/argon2"
 )
 
+// Legacy AES-CBC format used by tezbake before the versioned AEAD format
+// (see aead.go) was introduced. It is kept only to read and migrate existing
+// files. Do not use it for writing new data.
+
 const (
 	AES_DATA_PREFIX = "bake-buddy"
+	// LegacySaltSize is the size of the salt appended to the end of legacy encrypted files
+	LegacySaltSize = 16
 )
 
 func PrepareAESKey(password string, salt []byte) []byte {
@@ -26,20 +29,13 @@ func PrepareAESKey(password string, salt []byte) []byte {
 	return argon2.IDKey([]byte(password), salt, time, memory, threads, 32)
 }
 
-func pad(data []byte) []byte {
-	data = append([]byte(AES_DATA_PREFIX), data...)
-	blockSize := aes.BlockSize
-	padding := blockSize - len(data)%blockSize
-	if padding == 0 {
-		padding = blockSize
-	}
-	padtext := bytes.Repeat([]byte{byte(padding)}, padding)
-	return append(data, padtext...)
-}
 func unpad(data []byte) ([]byte, error) {
 	length := len(data)
+	if length == 0 {
+		return nil, errors.New("failed to decrypt data")
+	}
 	unpadding := int(data[length-1])
-	if !strings.HasPrefix(string(data), AES_DATA_PREFIX) {
+	if !strings.HasPrefix(string(data), AES_DATA_PREFIX) || unpadding > length-len(AES_DATA_PREFIX) {
 		return nil, errors.New("failed to decrypt data")
 	}
 	return data[len(AES_DATA_PREFIX):(length - unpadding)], nil
@@ -56,27 +52,25 @@ func DecryptAES(key, ciphertext []byte) ([]byte, error) {
 	}
 	iv := ciphertext[:aes.BlockSize]
 	ciphertext = ciphertext[aes.BlockSize:]
+	if len(ciphertext)%aes.BlockSize != 0 {
+		return nil, errors.New("ciphertext is not a multiple of the block size")
+	}
 
 	mode := cipher.NewCBCDecrypter(block, iv)
 	mode.CryptBlocks(ciphertext, ciphertext)
 	return unpad(ciphertext)
 }
 
-func EncryptAES(key, data []byte) ([]byte, error) {
-	block, err := aes.NewCipher(key)
-	if err != nil {
-		return nil, err
-	}
-	data = pad(data)
-
-	// The IV (Initialization Vector) needs to be unique, but not secure. It's common to include it at the beginning of the ciphertext.
-	ciphertext := make([]byte, aes.BlockSize+len(data))
-	iv := ciphertext[:aes.BlockSize]
-	if _, err := io.ReadFull(rand.Reader, iv); err != nil {
-		return nil, err
+// DecryptLegacy decrypts data in the legacy format - AES-CBC ciphertext followed by the argon2 salt.
+func DecryptLegacy(password string, data []byte) ([]byte, error) {
+	if len(data) < LegacySaltSize {
+		return nil, errors.New("data is too short to decrypt")
 	}
+	salt := data[len(data)-LegacySaltSize:]
+	// DecryptAES decrypts in place, keep the caller's data intact for another attempt
+	encData := make([]byte, len(data)-LegacySaltSize)
+	copy(encData, data)
 
-	mode := cipher.NewCBCEncrypter(block, iv)
-	mode.CryptBlocks(ciphertext[aes.BlockSize:], data)
-	return ciphertext, nil
+	key := PrepareAESKey(password, salt)
+	return DecryptAES(key, encData)
 }
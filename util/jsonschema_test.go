@@ -0,0 +1,48 @@
+package util
+
+import (
+	"reflect"
+	"testing"
+)
+
+type jsonSchemaTestBase struct {
+	Level string `json:"level"`
+}
+
+type jsonSchemaTestInfo struct {
+	jsonSchemaTestBase
+	Count    int               `json:"count"`
+	Tags     []string          `json:"tags,omitempty"`
+	Services map[string]string `json:"services"`
+	Stats    *struct {
+		Rate float64 `json:"rate"`
+	} `json:"stats,omitempty"`
+	Ignored  string `json:"-"`
+	internal string
+}
+
+func TestJsonSchemaOf(t *testing.T) {
+	schema := JsonSchemaOf(reflect.TypeOf(jsonSchemaTestInfo{}))
+	properties := schema["properties"].(map[string]any)
+
+	for _, name := range []string{"level", "count", "tags", "services", "stats"} {
+		if _, ok := properties[name]; !ok {
+			t.Errorf("expected property %s", name)
+		}
+	}
+	if _, ok := properties["Ignored"]; ok {
+		t.Errorf("ignored field should not be in schema")
+	}
+	if _, ok := properties["internal"]; ok {
+		t.Errorf("unexported field should not be in schema")
+	}
+	if !reflect.DeepEqual(schema["required"], []string{"level", "count", "services"}) {
+		t.Errorf("unexpected required fields %v", schema["required"])
+	}
+	if properties["count"].(map[string]any)["type"] != "integer" {
+		t.Errorf("count should be integer")
+	}
+	if _, ok := properties["stats"].(map[string]any)["anyOf"]; !ok {
+		t.Errorf("pointer should be nullable")
+	}
+}
@@ -57,6 +57,18 @@ func PromptPassword(message string) (string, error) {
 	return password, nil
 }
 
+// PromptText prompts for a line of text, empty input returns the default value
+func PromptText(message string, defaultValue string) (string, error) {
+	value, err := promptText(message, defaultValue)
+	if err != nil {
+		if errors.Is(err, ErrPromptCanceled) {
+			os.Exit(constants.ExitOperationCanceled)
+		}
+		return "", err
+	}
+	return value, nil
+}
+
 func promptConfirm(message string, defaultValue bool) (bool, error) {
 	model := newConfirmModel(message, defaultValue)
 	result, err := tea.NewProgram(model, tea.WithInput(os.Stdin), tea.WithOutput(os.Stdout)).Run()
@@ -89,6 +101,25 @@ func promptPassword(message string) (string, error) {
 	return finalModel.input.Value(), nil
 }
 
+func promptText(message string, defaultValue string) (string, error) {
+	model := newTextModel(message, defaultValue)
+	result, err := tea.NewProgram(model, tea.WithInput(os.Stdin), tea.WithOutput(os.Stdout)).Run()
+	if err != nil {
+		return "", err
+	}
+	finalModel, ok := result.(textModel)
+	if !ok {
+		return "", fmt.Errorf("unexpected text model type %T", result)
+	}
+	if finalModel.canceled {
+		return "", ErrPromptCanceled
+	}
+	if value := strings.TrimSpace(finalModel.input.Value()); value != "" {
+		return value, nil
+	}
+	return defaultValue, nil
+}
+
 func confirmFailureMessage(message string, failureMsg []string) string {
 	if len(failureMsg) > 0 && failureMsg[0] != "" {
 		return failureMsg[0]
@@ -200,3 +231,45 @@ func (m passwordModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 func (m passwordModel) View() string {
 	return fmt.Sprintf("%s %s\n", constants.StylePrompt.Render(m.prompt), m.input.View())
 }
+
+type textModel struct {
+	prompt   string
+	input    textinput.Model
+	canceled bool
+}
+
+func newTextModel(prompt string, defaultValue string) textModel {
+	input := textinput.New()
+	input.Prompt = ""
+	input.Placeholder = defaultValue
+	input.Focus()
+	return textModel{
+		prompt: prompt,
+		input:  input,
+	}
+}
+
+func (m textModel) Init() tea.Cmd {
+	return textinput.Blink
+}
+
+func (m textModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.KeyMsg:
+		switch msg.Type {
+		case tea.KeyCtrlC, tea.KeyEsc:
+			m.canceled = true
+			return m, tea.Quit
+		case tea.KeyEnter:
+			return m, tea.Quit
+		}
+	}
+
+	var cmd tea.Cmd
+	m.input, cmd = m.input.Update(msg)
+	return m, cmd
+}
+
+func (m textModel) View() string {
+	return fmt.Sprintf("%s %s\n", constants.StylePrompt.Render(m.prompt), m.input.View())
+}
@@ -0,0 +1,72 @@
+package util
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"runtime"
+	"strings"
+)
+
+// firstLine returns the first line of the output, `pass` and similar tools
+// print the secret on the first line followed by optional metadata
+func firstLine(output []byte) string {
+	line, _, _ := strings.Cut(string(output), "\n")
+	return strings.TrimRight(line, "\r")
+}
+
+// ReadSecretFromCommand runs the command through the shell and returns the first line of its output as the secret.
+func ReadSecretFromCommand(command string) (string, error) {
+	if strings.TrimSpace(command) == "" {
+		return "", errors.New("no command specified")
+	}
+	var stderr bytes.Buffer
+	cmd := exec.Command("/bin/sh", "-c", command)
+	cmd.Stdin = os.Stdin
+	cmd.Stderr = &stderr
+	output, err := cmd.Output()
+	if err != nil {
+		return "", fmt.Errorf("failed to run '%s' - %s %s", command, err.Error(), strings.TrimSpace(stderr.String()))
+	}
+	secret := firstLine(output)
+	if secret == "" {
+		return "", fmt.Errorf("command '%s' returned empty secret", command)
+	}
+	return secret, nil
+}
+
+func keyringCommand(service string, account string) (*exec.Cmd, error) {
+	switch runtime.GOOS {
+	case "linux":
+		// secret-tool talks to the Secret Service (gnome-keyring, kwallet, keepassxc...) over D-Bus
+		return exec.Command("secret-tool", "lookup", "service", service, "account", account), nil
+	case "darwin":
+		return exec.Command("security", "find-generic-password", "-s", service, "-a", account, "-w"), nil
+	default:
+		return nil, fmt.Errorf("keyring is not supported on %s", runtime.GOOS)
+	}
+}
+
+// ReadSecretFromKeyring looks up the secret stored under service and account in the OS keyring.
+func ReadSecretFromKeyring(service string, account string) (string, error) {
+	cmd, err := keyringCommand(service, account)
+	if err != nil {
+		return "", err
+	}
+	if cmd.Err != nil {
+		return "", fmt.Errorf("keyring tool '%s' not available - %s", cmd.Args[0], cmd.Err.Error())
+	}
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	output, err := cmd.Output()
+	if err != nil {
+		return "", fmt.Errorf("failed to read secret '%s/%s' from keyring - %s %s", service, account, err.Error(), strings.TrimSpace(stderr.String()))
+	}
+	secret := firstLine(output)
+	if secret == "" {
+		return "", fmt.Errorf("secret '%s/%s' not found in keyring", service, account)
+	}
+	return secret, nil
+}
@@ -1,25 +1,54 @@
 package util
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"net"
 	"net/http"
+	"os"
+	"strings"
+	"sync"
 	"time"
 
 	"github.com/tez-capital/tezbake/constants"
 	"go.alis.is/common/log"
 )
 
-func resolveSecondaryKey(pkh string) (string, error) {
-	url := fmt.Sprintf("%sv1/operations/update_secondary_key?publicKeyHash=%s&select=sender&sort.desc=level&limit=1", constants.TzktConsensusKeyCheckingEndpoint, pkh)
-	var bakers []struct {
-		Address string `json:"address"`
+const TzktUrlEnv = "TEZBAKE_TZKT_URL"
+
+var errNotFound = errors.New("not found")
+
+// limits of the delegate scan used to resolve consensus keys not found among current validators
+const (
+	maxScannedDelegates        = 1000
+	delegateScanConcurrency    = 16
+	delegateScanRequestTimeout = 10 * time.Second
+	delegateScanTimeout        = time.Minute
+)
+
+// AttestationProfileResolver resolves keys (delegate, consensus or companion) to attester profiles (delegates).
+// Node rpc is tried first, TzKT is used as a fallback.
+type AttestationProfileResolver struct {
+	// NodeRpcUrl is the node rpc used to resolve keys, skipped if empty
+	NodeRpcUrl string
+	// NodeRpcClient is used for node rpc requests (e.g. tunneled to remote node), defaults to a new http client
+	NodeRpcClient *http.Client
+	// TzktUrl is the base url of TzKT api used as a fallback, fallback is disabled if empty
+	TzktUrl string
+}
+
+// DefaultTzktUrl returns TzKT url from TEZBAKE_TZKT_URL or the public TzKT api
+func DefaultTzktUrl() string {
+	if tzktUrl := os.Getenv(TzktUrlEnv); tzktUrl != "" {
+		return tzktUrl
 	}
-	log.Info("Checking if key is a secondary key...", "key", pkh)
-	log.Debug("Checking through...", "url", url)
+	return constants.TzktConsensusKeyCheckingEndpoint
+}
 
-	client := &http.Client{
+func newHttpClient() *http.Client {
+	return &http.Client{
 		Transport: &http.Transport{
 			DialContext: (&net.Dialer{
 				Timeout: 30 * time.Second, // enforce dial timeout
@@ -28,70 +57,312 @@ func resolveSecondaryKey(pkh string) (string, error) {
 		},
 		Timeout: 2 * time.Minute,
 	}
+}
+
+// getJson decodes json response of the url into result, returns errNotFound on 404
+func getJson(client *http.Client, url string, result any) error {
+	log.Debug("Requesting...", "url", url)
 	response, err := client.Get(url)
 	if err != nil {
-		return "", err
+		return err
 	}
 	defer response.Body.Close()
-	if response.StatusCode != http.StatusOK {
-		return "", fmt.Errorf("failed to check whether key %s is a consensus key: %s", pkh, response.Status)
+	switch response.StatusCode {
+	case http.StatusOK:
+		return json.NewDecoder(response.Body).Decode(result)
+	case http.StatusNotFound, http.StatusNoContent:
+		return errNotFound
+	default:
+		return fmt.Errorf("unexpected response status %s", response.Status)
 	}
-	err = json.NewDecoder(response.Body).Decode(&bakers)
-	if err != nil {
+}
+
+// TzktGet decodes json response of the TzKT api path into result
+func TzktGet(tzktUrl string, path string, result any) error {
+	return getJson(newHttpClient(), joinUrl(tzktUrl, path), result)
+}
+
+func joinUrl(base string, path string) string {
+	return strings.TrimSuffix(base, "/") + "/" + strings.TrimPrefix(path, "/")
+}
+
+type rpcKey struct {
+	Pkh string `json:"pkh"`
+}
+
+type rpcDelegateKeys struct {
+	Active   *rpcKey  `json:"active"`
+	Pendings []rpcKey `json:"pendings"`
+}
+
+func (keys *rpcDelegateKeys) pkhs() []string {
+	if keys == nil {
+		return nil
+	}
+	result := make([]string, 0, len(keys.Pendings)+1)
+	if keys.Active != nil {
+		result = append(result, keys.Active.Pkh)
+	}
+	for _, pending := range keys.Pendings {
+		result = append(result, pending.Pkh)
+	}
+	return result
+}
+
+func (keys *rpcDelegateKeys) contains(pkh string) bool {
+	if keys == nil {
+		return false
+	}
+	if keys.Active != nil && keys.Active.Pkh == pkh {
+		return true
+	}
+	for _, pending := range keys.Pendings {
+		if pending.Pkh == pkh {
+			return true
+		}
+	}
+	return false
+}
+
+type rpcDelegate struct {
+	Deactivated  bool             `json:"deactivated"`
+	ConsensusKey *rpcDelegateKeys `json:"consensus_key"`
+	CompanionKey *rpcDelegateKeys `json:"companion_key"`
+}
+
+type rpcValidator struct {
+	Delegate     string `json:"delegate"`
+	ConsensusKey string `json:"consensus_key"`
+	CompanionKey string `json:"companion_key"`
+}
+
+func resolveAttestationProfileFromNode(client *http.Client, rpcUrl string, pkh string) (string, error) {
+	const head = "/chains/main/blocks/head"
+	log.Info("Checking if key is a delegate through node rpc...", "key", pkh)
+
+	var delegate rpcDelegate
+	err := getJson(client, joinUrl(rpcUrl, fmt.Sprintf("%s/context/delegates/%s", head, pkh)), &delegate)
+	switch {
+	case err == nil:
+		if delegate.Deactivated {
+			log.Warn("Key is not active:", "key", pkh)
+		}
+		return pkh, nil
+	case !errors.Is(err, errNotFound):
 		return "", err
 	}
+
+	// active keys of delegates with rights at the current level
+	var validators []rpcValidator
+	if err := getJson(client, joinUrl(rpcUrl, head+"/helpers/validators"), &validators); err == nil {
+		for _, validator := range validators {
+			if validator.ConsensusKey == pkh || validator.CompanionKey == pkh {
+				return validator.Delegate, nil
+			}
+		}
+	}
+
+	// check active and pending keys of all active delegates
+	var delegates []string
+	if err := getJson(client, joinUrl(rpcUrl, head+"/context/delegates?active=true"), &delegates); err != nil {
+		return "", err
+	}
+	if len(delegates) > maxScannedDelegates {
+		log.Warn("Too many active delegates, scanning only part of them.", "delegates", len(delegates), "scanned", maxScannedDelegates)
+		delegates = delegates[:maxScannedDelegates]
+	}
+	if owner, found := scanDelegatesForKey(client, rpcUrl, delegates, pkh); found {
+		return owner, nil
+	}
+	return "", fmt.Errorf("key %s is neither a delegate nor a consensus key", pkh)
+}
+
+// scanDelegatesForKey looks up the delegate with pkh as active or pending consensus or companion key,
+// delegates are requested concurrently with a short timeout and the scan stops on the first match
+func scanDelegatesForKey(client *http.Client, rpcUrl string, delegates []string, pkh string) (string, bool) {
+	scanClient := &http.Client{Transport: client.Transport, Timeout: delegateScanRequestTimeout}
+	ctx, cancel := context.WithTimeout(context.Background(), delegateScanTimeout)
+	defer cancel()
+
+	toScan := make(chan string)
+	found := make(chan string, 1)
+	var wg sync.WaitGroup
+	for range min(delegateScanConcurrency, len(delegates)) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for delegatePkh := range toScan {
+				var delegate rpcDelegate
+				if err := getJson(scanClient, joinUrl(rpcUrl, fmt.Sprintf("/chains/main/blocks/head/context/delegates/%s", delegatePkh)), &delegate); err != nil {
+					continue
+				}
+				if delegate.ConsensusKey.contains(pkh) || delegate.CompanionKey.contains(pkh) {
+					select {
+					case found <- delegatePkh:
+						cancel()
+					default:
+					}
+				}
+			}
+		}()
+	}
+feed:
+	for _, delegatePkh := range delegates {
+		select {
+		case toScan <- delegatePkh:
+		case <-ctx.Done():
+			break feed
+		}
+	}
+	close(toScan)
+	wg.Wait()
+
+	select {
+	case owner := <-found:
+		return owner, true
+	default:
+		if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+			log.Warn("Scan of delegates timed out.", "key", pkh, "timeout", delegateScanTimeout.String())
+		}
+		return "", false
+	}
+}
+
+func resolveSecondaryKey(client *http.Client, tzktUrl string, pkh string) (string, error) {
+	url := joinUrl(tzktUrl, fmt.Sprintf("v1/operations/update_secondary_key?publicKeyHash=%s&select=sender&sort.desc=level&limit=1", pkh))
+	var bakers []struct {
+		Address string `json:"address"`
+	}
+	log.Info("Checking if key is a secondary key...", "key", pkh)
+	if err := getJson(client, url, &bakers); err != nil {
+		return "", fmt.Errorf("failed to check whether key %s is a consensus key: %s", pkh, err.Error())
+	}
 	if len(bakers) > 0 {
 		return bakers[0].Address, nil
 	}
 	return "", fmt.Errorf("key %s is not a consensus key", pkh)
 }
 
-func ResolveAttestationProfile(pkh string) (string, error) {
-	url := fmt.Sprintf("%sv1/delegates/%s", constants.TzktConsensusKeyCheckingEndpoint, pkh)
+func resolveAttestationProfileFromTzkt(client *http.Client, tzktUrl string, pkh string) (string, error) {
 	var delegate struct {
 		Active bool `json:"active"`
 	}
 	log.Info("Checking if key is a delegate...", "key", pkh)
-	log.Debug("Checking through...", "url", url)
-	client := &http.Client{
-		Transport: &http.Transport{
-			DialContext: (&net.Dialer{
-				Timeout: 30 * time.Second, // enforce dial timeout
-			}).DialContext,
-			TLSHandshakeTimeout: 30 * time.Second,
-		},
-		Timeout: 2 * time.Minute,
+	err := getJson(client, joinUrl(tzktUrl, fmt.Sprintf("v1/delegates/%s", pkh)), &delegate)
+	switch {
+	case errors.Is(err, errNotFound):
+	case err != nil:
+		log.Warn("Failed to check whether key is a delegate:", "key", pkh, "error", err.Error())
+		return pkh, nil
+	case !delegate.Active:
+		log.Warn("Key is not active:", "key", pkh)
+		return pkh, nil
+	default:
+		return pkh, nil
 	}
-	response, err := client.Get(url)
+
+	secondaryKeyOwner, err := resolveSecondaryKey(client, tzktUrl, pkh)
 	if err != nil {
 		return "", err
 	}
-	defer response.Body.Close()
-	switch {
-	case response.StatusCode == http.StatusNotFound:
-	case response.StatusCode == http.StatusNoContent:
+	return secondaryKeyOwner, nil
+}
 
-	default:
-		switch {
-		case response.StatusCode == http.StatusOK:
-			err = json.NewDecoder(response.Body).Decode(&delegate)
-			switch {
-			case err != nil:
-				log.Warn("Failed to decode response for key:", "key", pkh, "error", err)
-			case !delegate.Active:
-				log.Warn("Key is not active:", "key", pkh)
+func (resolver *AttestationProfileResolver) nodeRpcClient(fallback *http.Client) *http.Client {
+	if resolver.NodeRpcClient != nil {
+		return resolver.NodeRpcClient
+	}
+	return fallback
+}
+
+func (resolver *AttestationProfileResolver) Resolve(pkh string) (string, error) {
+	client := newHttpClient()
+	if resolver.NodeRpcUrl != "" {
+		profile, err := resolveAttestationProfileFromNode(resolver.nodeRpcClient(client), resolver.NodeRpcUrl, pkh)
+		if err == nil {
+			if profile != pkh {
+				log.Info("Key is a consensus key for delegate", "key", pkh, "delegate", profile)
 			}
-		default:
-			log.Warn("Failed to check whether key is a delegate:", "key", pkh, "response_status", response.Status)
+			return profile, nil
 		}
-		return pkh, nil
+		log.Warn("Failed to resolve attestation profile through node rpc", "key", pkh, "error", err.Error())
 	}
 
-	secondaryKeyOwner, err := resolveSecondaryKey(pkh)
-	if err == nil {
-		log.Info("Key is a secondary key for delegate", "key", pkh, "delegate", secondaryKeyOwner)
-		return secondaryKeyOwner, nil
+	if resolver.TzktUrl != "" {
+		profile, err := resolveAttestationProfileFromTzkt(client, resolver.TzktUrl, pkh)
+		if err == nil {
+			if profile != pkh {
+				log.Info("Key is a secondary key for delegate", "key", pkh, "delegate", profile)
+			}
+			return profile, nil
+		}
+		log.Debug("Failed to resolve attestation profile through TzKT", "key", pkh, "error", err.Error())
 	}
 
 	return "", fmt.Errorf("failed to resolve attestation profile for key %s", pkh)
 }
+
+type DelegateKeyKind string
+
+const (
+	DelegateKeyKindDelegate  DelegateKeyKind = "delegate"
+	DelegateKeyKindConsensus DelegateKeyKind = "consensus"
+	DelegateKeyKindCompanion DelegateKeyKind = "companion"
+)
+
+// DelegateKey tells which delegate the key belongs to and how
+type DelegateKey struct {
+	Delegate string          `json:"delegate"`
+	Kind     DelegateKeyKind `json:"kind"`
+}
+
+// ResolveDelegateKeys looks up keys through node rpc among delegates, their active and pending consensus and companion keys
+// and keys of current validators. Keys not found are omitted, delegates are not scanned.
+func (resolver *AttestationProfileResolver) ResolveDelegateKeys(pkhs []string) (map[string]DelegateKey, error) {
+	const head = "/chains/main/blocks/head"
+	if resolver.NodeRpcUrl == "" {
+		return nil, errors.New("node rpc not available")
+	}
+	client := resolver.nodeRpcClient(newHttpClient())
+
+	found := map[string]DelegateKey{}
+	addKey := func(pkh string, key DelegateKey) {
+		if _, ok := found[pkh]; !ok && pkh != "" && pkh != key.Delegate {
+			found[pkh] = key
+		}
+	}
+	for _, pkh := range pkhs {
+		var delegate rpcDelegate
+		err := getJson(client, joinUrl(resolver.NodeRpcUrl, fmt.Sprintf("%s/context/delegates/%s", head, pkh)), &delegate)
+		switch {
+		case errors.Is(err, errNotFound):
+			continue
+		case err != nil:
+			return nil, err
+		}
+		found[pkh] = DelegateKey{Delegate: pkh, Kind: DelegateKeyKindDelegate}
+		for _, key := range delegate.ConsensusKey.pkhs() {
+			addKey(key, DelegateKey{Delegate: pkh, Kind: DelegateKeyKindConsensus})
+		}
+		for _, key := range delegate.CompanionKey.pkhs() {
+			addKey(key, DelegateKey{Delegate: pkh, Kind: DelegateKeyKindCompanion})
+		}
+	}
+
+	// keys of delegates not among pkhs, only active keys of current validators are available
+	var validators []rpcValidator
+	if err := getJson(client, joinUrl(resolver.NodeRpcUrl, head+"/helpers/validators"), &validators); err == nil {
+		for _, validator := range validators {
+			addKey(validator.ConsensusKey, DelegateKey{Delegate: validator.Delegate, Kind: DelegateKeyKindConsensus})
+			addKey(validator.CompanionKey, DelegateKey{Delegate: validator.Delegate, Kind: DelegateKeyKindCompanion})
+		}
+	}
+
+	result := make(map[string]DelegateKey, len(pkhs))
+	for _, pkh := range pkhs {
+		if key, ok := found[pkh]; ok {
+			result[pkh] = key
+		}
+	}
+	return result, nil
+}
@@ -0,0 +1,98 @@
+package util
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func newJsonServer(t *testing.T, responses map[string]any) *httptest.Server {
+	t.Helper()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		response, ok := responses[r.URL.RequestURI()]
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		json.NewEncoder(w).Encode(response)
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestAttestationProfileResolver(t *testing.T) {
+	node := newJsonServer(t, map[string]any{
+		"/chains/main/blocks/head/context/delegates/tz1delegate": map[string]any{"deactivated": false},
+		"/chains/main/blocks/head/context/delegates/tz1other":    map[string]any{"consensus_key": map[string]any{"active": map[string]any{"pkh": "tz1other"}, "pendings": []any{map[string]any{"cycle": 10, "pkh": "tz4pending"}}}},
+		"/chains/main/blocks/head/helpers/validators":            []any{map[string]any{"delegate": "tz1delegate", "consensus_key": "tz4active"}},
+		"/chains/main/blocks/head/context/delegates?active=true": []string{"tz1delegate", "tz1other"},
+	})
+	tzkt := newJsonServer(t, map[string]any{
+		"/v1/operations/update_secondary_key?publicKeyHash=tz4tzkt&select=sender&sort.desc=level&limit=1": []any{map[string]any{"address": "tz1tzkt"}},
+	})
+
+	tests := []struct {
+		name     string
+		resolver AttestationProfileResolver
+		key      string
+		expected string
+	}{
+		{name: "Delegate", resolver: AttestationProfileResolver{NodeRpcUrl: node.URL}, key: "tz1delegate", expected: "tz1delegate"},
+		{name: "Active consensus key", resolver: AttestationProfileResolver{NodeRpcUrl: node.URL}, key: "tz4active", expected: "tz1delegate"},
+		{name: "Pending consensus key", resolver: AttestationProfileResolver{NodeRpcUrl: node.URL}, key: "tz4pending", expected: "tz1other"},
+		{name: "TzKT fallback", resolver: AttestationProfileResolver{NodeRpcUrl: node.URL, TzktUrl: tzkt.URL}, key: "tz4tzkt", expected: "tz1tzkt"},
+		{name: "TzKT only", resolver: AttestationProfileResolver{TzktUrl: tzkt.URL + "/"}, key: "tz4tzkt", expected: "tz1tzkt"},
+		{name: "Unknown without fallback", resolver: AttestationProfileResolver{NodeRpcUrl: node.URL}, key: "tz4tzkt", expected: ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			profile, err := tt.resolver.Resolve(tt.key)
+			if tt.expected == "" {
+				if err == nil {
+					t.Errorf("expected error, got %s", profile)
+				}
+				return
+			}
+			if err != nil || profile != tt.expected {
+				t.Errorf("expected %s, got %s (%v)", tt.expected, profile, err)
+			}
+		})
+	}
+}
+
+func TestResolveDelegateKeys(t *testing.T) {
+	node := newJsonServer(t, map[string]any{
+		"/chains/main/blocks/head/context/delegates/tz1baker": map[string]any{
+			"consensus_key": map[string]any{"active": map[string]any{"pkh": "tz4hot"}, "pendings": []any{map[string]any{"cycle": 10, "pkh": "tz4next"}}},
+			"companion_key": map[string]any{"active": map[string]any{"pkh": "tz4companion"}},
+		},
+		"/chains/main/blocks/head/helpers/validators": []any{map[string]any{"delegate": "tz1other", "consensus_key": "tz4other"}},
+	})
+
+	resolver := AttestationProfileResolver{NodeRpcUrl: node.URL}
+	keys, err := resolver.ResolveDelegateKeys([]string{"tz1baker", "tz4hot", "tz4next", "tz4companion", "tz4other", "tz1unknown"})
+	if err != nil {
+		t.Fatalf("failed to resolve keys: %v", err)
+	}
+	expected := map[string]DelegateKey{
+		"tz1baker":     {Delegate: "tz1baker", Kind: DelegateKeyKindDelegate},
+		"tz4hot":       {Delegate: "tz1baker", Kind: DelegateKeyKindConsensus},
+		"tz4next":      {Delegate: "tz1baker", Kind: DelegateKeyKindConsensus},
+		"tz4companion": {Delegate: "tz1baker", Kind: DelegateKeyKindCompanion},
+		"tz4other":     {Delegate: "tz1other", Kind: DelegateKeyKindConsensus},
+	}
+	if len(keys) != len(expected) {
+		t.Fatalf("expected %v, got %v", expected, keys)
+	}
+	for pkh, key := range expected {
+		if keys[pkh] != key {
+			t.Errorf("expected %v for %s, got %v", key, pkh, keys[pkh])
+		}
+	}
+
+	if _, err := (&AttestationProfileResolver{}).ResolveDelegateKeys([]string{"tz1baker"}); err == nil {
+		t.Errorf("expected error without node rpc")
+	}
+}